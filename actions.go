@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 在 GitHub Actions 中运行时把警告和错误输出为 workflow command（::warning、::error），
+// 显示在运行页面的 Annotations 中，不用到 error.log 里查找
+type actionsHandler struct {
+	attrs []slog.Attr
+}
+
+func (h *actionsHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn
+}
+
+func (h *actionsHandler) Handle(_ context.Context, record slog.Record) error {
+	command := "warning"
+	if record.Level >= slog.LevelError {
+		command = "error"
+	}
+
+	// RSS 地址放在最前面，其余属性按 key=value 列出
+	var feed string
+	var details []string
+	add := func(a slog.Attr) bool {
+		value := a.Value.Resolve().String()
+		if a.Key == "feed" && feed == "" {
+			feed = value
+		} else {
+			details = append(details, a.Key+"="+value)
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	record.Attrs(add)
+
+	message := strings.Join(details, " ")
+	if feed != "" {
+		message = strings.TrimSpace(feed + " " + message)
+	}
+	if message == "" {
+		message = record.Message
+	}
+
+	_, err := fmt.Fprintf(os.Stdout, "::%s title=%s::%s\n", command, escapeActionsProperty(record.Message), escapeActionsData(message))
+	return err
+}
+
+func (h *actionsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &actionsHandler{attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h *actionsHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// 转义 workflow command 的消息部分
+func escapeActionsData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// 转义 workflow command 的属性值，还需要转义 : 和 ,
+func escapeActionsProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeActionsData(s))
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// 存档索引中的一条记录
+type archiveEntry struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Link  string `json:"link"`
+	Date  string `json:"date"`
+	// RFC3339，用于排序
+	DateISO string `json:"dateIso"`
+	// 文章语言，例如 zh-CN
+	Language string `json:"language,omitempty"`
+}
+
+// 单篇文章的存档页
+var archivePageTemplate = newPageTemplate("archive", `<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+{{template "head"}}
+<title>{{.Article.Title}} - {{.Article.Name}}</title>
+</head>
+<body>
+<main>
+<article{{with .Article.Language}} lang="{{.}}"{{end}}>
+<header>
+<h1>{{.Article.Title}}</h1>
+<p class="meta">{{.Article.Name}} · <time datetime="{{.Article.DateISO}}">{{.Article.Date}}</time></p>
+</header>
+{{if .Summary}}<p>{{.Summary}}</p>{{end}}
+</article>
+<nav aria-label="文章链接">
+<ul>
+<li><a href="{{.Article.Link}}" aria-label="阅读原文：{{.Article.Title}}">原文</a></li>
+<li><a href="{{.Snapshot}}" aria-label="网页快照：{{.Article.Title}}">网页快照</a></li>
+<li><a href="index.html">全部存档</a></li>
+</ul>
+</nav>
+</main>
+</body>
+</html>
+`)
+
+// 存档索引页
+var archiveIndexTemplate = newPageTemplate("index", `<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+{{template "head"}}
+<title>友链文章存档</title>
+</head>
+<body>
+<main>
+<h1>友链文章存档</h1>
+<ul aria-label="存档文章列表">
+{{range .Entries}}<li{{with .Language}} lang="{{.}}"{{end}}><time datetime="{{.DateISO}}">{{.Date}}</time> <span class="meta">{{.Name}}</span>：<a href="{{.ID}}.html">{{.Title}}</a></li>
+{{end}}</ul>
+</main>
+</body>
+</html>
+`)
+
+// 文章存档 ID，使用文章链接的 SHA256 前 12 位
+func archiveID(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+var (
+	htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+	// 脚本、样式等不可见内容连同标签一起去除
+	htmlHiddenPattern  = regexp.MustCompile(`(?is)<(script|style|noscript|template)\b[^>]*>.*?</(script|style|noscript|template)\s*>`)
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// 去除 HTML 标签并截断为纯文本摘要
+func plainText(content string, maxRunes int) string {
+	text := htmlCommentPattern.ReplaceAllString(content, " ")
+	text = htmlHiddenPattern.ReplaceAllString(text, " ")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	text = cleanText(text)
+
+	return truncateRunes(text, maxRunes)
+}
+
+// 文章摘要：优先使用 description，为空时使用正文，maxRunes 为 0 时不生成
+func articleSummary(item *gofeed.Item, maxRunes int) string {
+	if maxRunes <= 0 {
+		return ""
+	}
+	if summary := plainText(item.Description, maxRunes); summary != "" {
+		return summary
+	}
+	return plainText(item.Content, maxRunes)
+}
+
+// 为新文章生成存档页，并更新存档索引
+func writeArchivePages(config Config, store Storage, articles []Article) error {
+	indexData, err := store.ReadFile("archive/index.json")
+	if err != nil {
+		return err
+	}
+
+	var index []archiveEntry
+	if indexData != nil {
+		if err := json.Unmarshal(indexData, &index); err != nil {
+			return fmt.Errorf("error parsing archive/index.json: %v", err)
+		}
+	}
+
+	archived := map[string]bool{}
+	for _, entry := range index {
+		archived[entry.ID] = true
+	}
+
+	added := 0
+	for _, article := range articles {
+		id := archiveID(article.Link)
+		if archived[id] {
+			continue
+		}
+		article.Date = archivedDate(article.Date, article.DateISO)
+
+		var page bytes.Buffer
+		err := archivePageTemplate.Execute(&page, map[string]interface{}{
+			"Lang":     config.SiteLanguage,
+			"Article":  article,
+			"Summary":  article.Summary,
+			"Snapshot": "https://web.archive.org/web/" + article.Link,
+		})
+		if err != nil {
+			return err
+		}
+		if err := store.WriteFile("archive/"+id+".html", page.Bytes()); err != nil {
+			return err
+		}
+
+		index = append(index, archiveEntry{
+			ID:       id,
+			Name:     article.Name,
+			Title:    article.Title,
+			Link:     article.Link,
+			Date:     article.Date,
+			DateISO:  article.DateISO,
+			Language: article.Language,
+		})
+		archived[id] = true
+		added++
+	}
+
+	if added == 0 {
+		return nil
+	}
+
+	// 之前写入的相对时间也换成日期
+	for i := range index {
+		index[i].Date = archivedDate(index[i].Date, index[i].DateISO)
+	}
+
+	// 按发布时间倒序
+	sort.SliceStable(index, func(i, j int) bool {
+		date1, _ := time.Parse(time.RFC3339, index[i].DateISO)
+		date2, _ := time.Parse(time.RFC3339, index[j].DateISO)
+		return date1.After(date2)
+	})
+
+	var indexPage bytes.Buffer
+	err = archiveIndexTemplate.Execute(&indexPage, map[string]interface{}{
+		"Lang":    config.SiteLanguage,
+		"Entries": index,
+	})
+	if err != nil {
+		return err
+	}
+	if err := store.WriteFile("archive/index.html", indexPage.Bytes()); err != nil {
+		return err
+	}
+
+	indexData, err = json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("archive/index.json", indexData)
+}
+
+// 将文章累积写入 archive.json，保留每一篇抓取到的文章，用于展示完整的友链时间线。
+// 发布时间早于保留期限的文章会被移除，retention 为 0 时永久保留
+func writeArticleArchive(config Config, store Storage, articles []Article) error {
+	data, err := store.ReadFile("archive.json")
+	if err != nil {
+		return err
+	}
+
+	var archive []Article
+	if data != nil {
+		if err := json.Unmarshal(data, &archive); err != nil {
+			return fmt.Errorf("error parsing archive.json: %v", err)
+		}
+	}
+
+	// 以链接去重，新抓取的数据覆盖旧数据（例如标题修改）
+	index := map[string]int{}
+	for i, article := range archive {
+		index[article.Link] = i
+	}
+	for _, article := range articles {
+		if i, ok := index[article.Link]; ok {
+			archive[i] = article
+			continue
+		}
+		index[article.Link] = len(archive)
+		archive = append(archive, article)
+	}
+
+	// 移除超过保留期限的文章
+	if config.ArchiveRetention > 0 {
+		cutoff := time.Now().Add(-config.ArchiveRetention)
+		kept := archive[:0]
+		for _, article := range archive {
+			published, err := time.Parse(time.RFC3339, article.DateISO)
+			if err == nil && published.Before(cutoff) {
+				continue
+			}
+			kept = append(kept, article)
+		}
+		archive = kept
+	}
+
+	// 相对时间会过时，存档中保存日期，包括之前写入的相对时间
+	for i := range archive {
+		archive[i].Date = archivedDate(archive[i].Date, archive[i].DateISO)
+	}
+
+	// 按发布时间倒序
+	sort.SliceStable(archive, func(i, j int) bool {
+		date1, _ := time.Parse(time.RFC3339, archive[i].DateISO)
+		date2, _ := time.Parse(time.RFC3339, archive[j].DateISO)
+		return date1.After(date2)
+	})
+
+	jsonData, err := json.Marshal(archive)
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("archive.json", jsonData)
+}
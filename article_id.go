@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// 文章唯一标识的计算方式，用于去重、历史记录和 Webhook 事件 ID。
+// 有些博客程序每次生成 RSS 时 GUID 都会变化，这类 RSS 需要改用链接或标题
+var articleIDStrategies = map[string]func(article Article) string{
+	// RSS 中的 GUID，没有时使用链接
+	"guid": func(article Article) string {
+		if article.guid != "" {
+			return article.guid
+		}
+		return article.Link
+	},
+	// 文章链接
+	"link": func(article Article) string {
+		return article.Link
+	},
+	// 去掉查询参数和锚点的文章链接，适用于链接带有 utm_source 等跟踪参数的 RSS
+	"link-no-query": func(article Article) string {
+		u, err := url.Parse(article.Link)
+		if err != nil {
+			return article.Link
+		}
+		u.RawQuery, u.Fragment, u.RawFragment = "", "", ""
+		return u.String()
+	},
+	// 博客主机名加文章标题，适用于链接也不稳定的 RSS
+	"title": func(article Article) string {
+		return normalizedHost(article.feedURL) + "\n" + article.Title
+	},
+}
+
+// 默认的文章标识计算方式，见 ARTICLE_ID
+var defaultArticleID = "guid"
+
+// 支持的计算方式，用于错误信息
+func articleIDStrategyNames() string {
+	names := make([]string, 0, len(articleIDStrategies))
+	for name := range articleIDStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// 按配置设置默认的文章标识计算方式
+func configureArticleID(config Config) error {
+	if _, ok := articleIDStrategies[config.ArticleID]; !ok {
+		return fmt.Errorf("unsupported ARTICLE_ID %q, expected one of %s", config.ArticleID, articleIDStrategyNames())
+	}
+	defaultArticleID = config.ArticleID
+	return nil
+}
+
+// 文章使用的计算方式：RSS 列表中的 id 选项，没有时使用 ARTICLE_ID
+func articleIDStrategy(article Article) string {
+	if name := article.options["id"]; name != "" {
+		if _, ok := articleIDStrategies[name]; ok {
+			return name
+		}
+	}
+	return defaultArticleID
+}
+
+// 输出的 RSS、JSON Feed 中使用的 GUID，只有按 GUID 识别文章时才沿用 RSS 中的 GUID
+func articleGUID(article Article) string {
+	if articleIDStrategy(article) != "guid" {
+		return ""
+	}
+	return article.guid
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// audit.log 中的一条记录，每次成功发布追加一行 JSON。
+// 每条记录包含上一条的哈希，修改或删除任何一条都会使之后的链条对不上；
+// 设置 AUDIT_KEY 时还会用 HMAC-SHA256 签名，没有密钥的人无法重新计算整条链
+type auditEntry struct {
+	// 从 1 开始的序号
+	Seq int `json:"seq"`
+	// 发布时间，RFC3339
+	Time string `json:"time"`
+	// 常驻模式下的分组
+	Tier string `json:"tier,omitempty"`
+	// 触发本次运行的维护者，见 MAINTAINER
+	Maintainer string `json:"maintainer,omitempty"`
+	// 不含密钥的配置的 SHA-256
+	ConfigHash string `json:"configHash"`
+	// RSS 列表的 SHA-256
+	FeedsHash string `json:"feedsHash"`
+	// 发布的 rss_data.json 的 SHA-256
+	OutputHash string `json:"outputHash"`
+	// 发布的文章数量
+	Articles int `json:"articles"`
+	// 上一条记录的 hash，第一条为空
+	Prev string `json:"prev"`
+	// 本条记录（不含 hash、signature）的 SHA-256
+	Hash string `json:"hash"`
+	// 以 AUDIT_KEY 对 hash 的 HMAC-SHA256
+	Signature string `json:"signature,omitempty"`
+}
+
+// 不计入配置哈希的字段：密钥、令牌以及可能包含令牌的地址
+var auditSecretField = regexp.MustCompile(`(?i)token|secret|password|key|dsn|webhook|chatid`)
+
+// 配置的 SHA-256，按字段名排列，跳过密钥，轮换密钥不会改变配置哈希
+func auditConfigHash(config Config) string {
+	v := reflect.ValueOf(config)
+	t := v.Type()
+	var b strings.Builder
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() || auditSecretField.MatchString(t.Field(i).Name) {
+			continue
+		}
+		fmt.Fprintf(&b, "%s=%v\n", t.Field(i).Name, v.Field(i).Interface())
+	}
+	return sha256Hex([]byte(b.String()))
+}
+
+// 计算记录的哈希，不包含 hash 和 signature 本身
+func (e auditEntry) digest() string {
+	e.Hash, e.Signature = "", ""
+	data, _ := json.Marshal(e)
+	return sha256Hex(data)
+}
+
+// 对哈希签名
+func auditSignature(key string, hash string) string {
+	return hex.EncodeToString(hmacSHA256([]byte(key), hash))
+}
+
+// 读取 audit.log 中的全部记录
+func loadAuditLog(store Storage) ([]auditEntry, error) {
+	data, err := store.ReadFile("audit.log")
+	if err != nil {
+		return nil, err
+	}
+	return parseAuditLog(data)
+}
+
+// 解析 audit.log，每行一条记录
+func parseAuditLog(data []byte) ([]auditEntry, error) {
+	var entries []auditEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("error parsing audit.log line %d: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// 发布成功后追加一条审计记录，输出的哈希取自存储中实际发布的 rss_data.json
+func appendAudit(config Config, store Storage, tier string, feedLines []string, articles int) error {
+	if !config.AuditLog {
+		return nil
+	}
+
+	existing, err := store.ReadFile("audit.log")
+	if err != nil {
+		return err
+	}
+	entries, err := parseAuditLog(existing)
+	if err != nil {
+		return err
+	}
+	output, err := store.ReadFile("rss_data.json")
+	if err != nil {
+		return err
+	}
+
+	entry := auditEntry{
+		Seq:        1,
+		Time:       time.Now().Format(time.RFC3339),
+		Tier:       tier,
+		Maintainer: currentMaintainer,
+		ConfigHash: auditConfigHash(config),
+		FeedsHash:  sha256Hex([]byte(strings.Join(feedLines, "\n"))),
+		OutputHash: sha256Hex(output),
+		Articles:   articles,
+	}
+	if n := len(entries); n > 0 {
+		entry.Seq = entries[n-1].Seq + 1
+		entry.Prev = entries[n-1].Hash
+	}
+	entry.Hash = entry.digest()
+	if config.AuditKey != "" {
+		entry.Signature = auditSignature(config.AuditKey, entry.Hash)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		existing = append(existing, '\n')
+	}
+	return store.WriteFile("audit.log", append(existing, append(line, '\n')...))
+}
+
+// 校验 audit.log 的哈希链和签名，返回第一处错误
+func verifyAuditLog(entries []auditEntry, key string) error {
+	prev := ""
+	for i, entry := range entries {
+		if entry.Prev != prev {
+			return fmt.Errorf("entry %d: previous hash %s does not match %s", entry.Seq, entry.Prev, prev)
+		}
+		if i > 0 && entry.Seq != entries[i-1].Seq+1 {
+			return fmt.Errorf("entry %d: expected sequence %d", entry.Seq, entries[i-1].Seq+1)
+		}
+		if digest := entry.digest(); entry.Hash != digest {
+			return fmt.Errorf("entry %d: hash %s does not match its content (%s)", entry.Seq, entry.Hash, digest)
+		}
+		if key != "" && !hmac.Equal([]byte(entry.Signature), []byte(auditSignature(key, entry.Hash))) {
+			return fmt.Errorf("entry %d: invalid signature", entry.Seq)
+		}
+		prev = entry.Hash
+	}
+	return nil
+}
+
+// 处理 audit 子命令：audit verify 校验整条链，audit find <sha256> 查找产生某个 rss_data.json 的记录
+func runAuditCommand(config Config, store Storage, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: audit verify | audit find <sha256>")
+	}
+	entries, err := loadAuditLog(store)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "verify":
+		if err := verifyAuditLog(entries, config.AuditKey); err != nil {
+			return err
+		}
+		signed := "unsigned"
+		if config.AuditKey != "" {
+			signed = "signatures OK"
+		}
+		fmt.Printf("audit.log OK: %d entries, %s\n", len(entries), signed)
+		return nil
+	case "find":
+		if len(args) < 2 || args[1] == "" {
+			return fmt.Errorf("usage: audit find <sha256>")
+		}
+		found := false
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.OutputHash, strings.ToLower(args[1])) {
+				found = true
+				fmt.Printf("#%d %s articles=%d config=%s feeds=%s hash=%s", entry.Seq, entry.Time, entry.Articles, shortHash(entry.ConfigHash), shortHash(entry.FeedsHash), shortHash(entry.Hash))
+				if entry.Maintainer != "" {
+					fmt.Printf(" maintainer=%s", entry.Maintainer)
+				}
+				fmt.Println()
+			}
+		}
+		if !found {
+			return fmt.Errorf("no audit entry produced output %s", args[1])
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown audit command: %s", args[0])
+	}
+}
+
+// 输出时缩短的哈希
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html"
+)
+
+// 头像文件大小上限
+const avatarMaxSize = 512 << 10
+
+var avatars = &fileIndex{name: "avatars/index.json"}
+
+func init() {
+	registerEnricher(enricher{name: "avatar", run: enrichAvatar, finish: avatars.save})
+}
+
+// RSS 中声明的图片地址
+func feedImageURL(feed *gofeed.Feed) string {
+	if feed.Image == nil {
+		return ""
+	}
+	return strings.TrimSpace(feed.Image.URL)
+}
+
+// 为文章设置博客头像：依次尝试 RSS 中的图片、主页声明的图标和 /favicon.ico，
+// 下载后缓存到数据目录，AVATAR_REFRESH 内不重复获取
+func enrichAvatar(ctx context.Context, config Config, store Storage, article *Article) (bool, error) {
+	// RSS 列表中指定了头像时不再获取
+	if !config.Avatars || article.options["avatar"] != "" || article.DomainName == "" || article.DomainName == "unknown" {
+		return false, nil
+	}
+	site := article.DomainName
+
+	entry, err := avatars.get(store, site)
+	if err != nil {
+		return false, err
+	}
+	if entry != nil && !allowedImageFile(entry.File) {
+		entry = nil
+	}
+
+	if entry == nil || time.Since(entry.Updated) >= config.AvatarRefresh {
+		refreshed, err := fetchAvatar(ctx, store, site, article.siteURL, article.feedImage, entry)
+		if err != nil && entry == nil {
+			return false, err
+		}
+		// 获取失败时继续使用之前缓存的头像
+		if err == nil {
+			entry = refreshed
+			avatars.set(site, *entry)
+		}
+	}
+
+	avatar := dataFileURL(config, entry.File)
+	if article.Avatar == avatar {
+		return false, nil
+	}
+	article.Avatar = avatar
+	return true, nil
+}
+
+// 下载博客头像并写入数据目录，内容与之前相同时只更新时间
+func fetchAvatar(ctx context.Context, store Storage, site string, home string, feedImage string, previous *fileEntry) (*fileEntry, error) {
+	if home == "" {
+		home = site
+	}
+
+	candidates := []string{}
+	if feedImage != "" {
+		candidates = append(candidates, feedImage)
+	}
+	if icon := discoverIconURL(ctx, home); icon != "" {
+		candidates = append(candidates, icon)
+	}
+	if base, err := url.Parse(home); err == nil {
+		candidates = append(candidates, base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String())
+	}
+
+	var lastErr error
+	for _, source := range candidates {
+		data, ext, err := downloadImage(ctx, source, avatarMaxSize)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		entry := &fileEntry{
+			Source:  source,
+			File:    "avatars/" + archiveID(site) + ext,
+			Hash:    hex.EncodeToString(sum[:]),
+			Updated: time.Now(),
+		}
+		if previous != nil && previous.File == entry.File && previous.Hash == entry.Hash {
+			return entry, nil
+		}
+		if err := store.WriteFile(entry.File, data); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+	return nil, fmt.Errorf("no avatar found for %s: %v", site, lastErr)
+}
+
+// 从博客主页的 <link rel="icon"> 或 <link rel="apple-touch-icon"> 中找到图标地址，优先使用 apple-touch-icon
+func discoverIconURL(ctx context.Context, home string) string {
+	base, err := url.Parse(home)
+	if err != nil {
+		return ""
+	}
+
+	if err := checkRobots(ctx, home); err != nil {
+		return ""
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, home, nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	var icon string
+	z := html.NewTokenizer(io.LimitReader(resp.Body, 1<<20))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return icon
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		switch string(name) {
+		case "body":
+			// <link> 只出现在 <head> 中
+			return icon
+		case "link":
+		default:
+			continue
+		}
+
+		var rel, href string
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			switch string(key) {
+			case "rel":
+				rel = strings.ToLower(string(val))
+			case "href":
+				href = strings.TrimSpace(string(val))
+			}
+		}
+
+		rels := " " + rel + " "
+		touch := strings.Contains(rels, " apple-touch-icon ")
+		if href == "" || !touch && !strings.Contains(rels, " icon ") {
+			continue
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		icon = base.ResolveReference(ref).String()
+		if touch {
+			return icon
+		}
+	}
+}
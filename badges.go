@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"time"
+	"unicode/utf8"
+)
+
+// 徽章颜色，与 shields.io 一致
+const (
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeRed    = "#e05d44"
+	badgeGrey   = "#9f9f9f"
+)
+
+// 生成 shields.io 风格的 SVG 徽章
+func renderBadge(label string, value string, color string) []byte {
+	// Verdana 11px 下每个字符约 7px，两侧各留 5px
+	labelWidth := utf8.RuneCountInString(label)*7 + 10
+	valueWidth := utf8.RuneCountInString(value)*7 + 10
+	width := labelWidth + valueWidth
+
+	label, value = html.EscapeString(label), html.EscapeString(value)
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+<title>%s: %s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%d" y="14">%s</text><text x="%d" y="14">%s</text>
+</g>
+</svg>
+`, width, label, value, label, value, width, labelWidth, labelWidth, valueWidth, color, width, labelWidth/2, label, labelWidth+valueWidth/2, value))
+}
+
+// 将时间差格式化为 2h ago 这样的相对时间
+func relativeAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// 单个 RSS 的状态徽章
+func feedBadge(h *feedHealth) []byte {
+	switch {
+	case h == nil:
+		return renderBadge("feed", "unknown", badgeGrey)
+	case h.Disabled:
+		return renderBadge("feed", "disabled", badgeGrey)
+	case h.ConsecutiveFailures > 0:
+		return renderBadge("feed", fmt.Sprintf("failing (%d)", h.ConsecutiveFailures), badgeRed)
+	default:
+		return renderBadge("feed", "ok", badgeGreen)
+	}
+}
+
+// 生成并发布徽章：
+//
+//	badges/feeds.svg        feeds: 92/95 ok
+//	badges/last-update.svg  last update: 2h ago（最新一篇文章的发布时间）
+//	badges/<id>.svg         单个 RSS 的状态，id 与 badges/index.json 对应
+func writeBadges(store Storage, health map[string]*feedHealth, active []string, articles []Article) error {
+	ok := 0
+	for _, feedURL := range active {
+		if h := health[feedURL]; h != nil && !h.Disabled && h.ConsecutiveFailures == 0 {
+			ok++
+		}
+	}
+
+	color := badgeGreen
+	switch {
+	case len(active) == 0 || ok*100 < len(active)*80:
+		color = badgeRed
+	case ok*100 < len(active)*95:
+		color = badgeYellow
+	}
+	if err := store.WriteFile("badges/feeds.svg", renderBadge("feeds", fmt.Sprintf("%d/%d ok", ok, len(active)), color)); err != nil {
+		return err
+	}
+
+	var latest time.Time
+	for _, article := range articles {
+		if article.published.After(latest) {
+			latest = article.published
+		}
+	}
+	lastUpdate := renderBadge("last update", "never", badgeGrey)
+	if !latest.IsZero() {
+		age := time.Since(latest)
+		color := badgeGreen
+		switch {
+		case age > 30*24*time.Hour:
+			color = badgeRed
+		case age > 7*24*time.Hour:
+			color = badgeYellow
+		}
+		lastUpdate = renderBadge("last update", relativeAge(age), color)
+	}
+	if err := store.WriteFile("badges/last-update.svg", lastUpdate); err != nil {
+		return err
+	}
+
+	// RSS 地址 -> 徽章文件
+	index := map[string]string{}
+	for _, feedURL := range active {
+		name := "badges/" + archiveID(feedURL) + ".svg"
+		if err := store.WriteFile(name, feedBadge(health[feedURL])); err != nil {
+			return err
+		}
+		index[feedURL] = name
+	}
+
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("badges/index.json", indexData)
+}
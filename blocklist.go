@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 屏蔽列表中的一条记录，格式：<域名或 URL 前缀> <日期> <原因>
+//
+//	example.com 2024-05-01 博客已停更，域名被抢注
+//	https://old.example.org/feed 2024-06-12 迁移后旧地址重定向到广告页
+type blockEntry struct {
+	// 域名（包含子域名）或以 http:// / https:// 开头的 URL 前缀
+	Pattern string
+	// 加入屏蔽列表的日期
+	Date string
+	// 屏蔽原因
+	Reason string
+}
+
+// 屏蔽列表，已移除的博客不会通过重定向、推荐等方式重新出现
+type blocklist []blockEntry
+
+// 解析屏蔽列表，跳过空行和注释
+func parseBlocklist(data []byte) blocklist {
+	var list blocklist
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		entry := blockEntry{Pattern: strings.TrimPrefix(strings.ToLower(fields[0]), "*.")}
+		if len(fields) > 1 {
+			entry.Date = fields[1]
+		}
+		if len(fields) > 2 {
+			entry.Reason = strings.Join(fields[2:], " ")
+		}
+		list = append(list, entry)
+	}
+	return list
+}
+
+// 从存储后端读取 blocklist.txt，文件不存在时返回空列表
+func loadBlocklist(store Storage) (blocklist, error) {
+	data, err := store.ReadFile("blocklist.txt")
+	if err != nil {
+		return nil, err
+	}
+	return parseBlocklist(data), nil
+}
+
+// 检查 URL 是否被屏蔽
+func (b blocklist) match(rawURL string) (blockEntry, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return blockEntry{}, false
+	}
+	host := strings.ToLower(u.Hostname())
+	lowerURL := strings.ToLower(rawURL)
+
+	for _, entry := range b {
+		if strings.Contains(entry.Pattern, "://") {
+			if strings.HasPrefix(lowerURL, entry.Pattern) {
+				return entry, true
+			}
+			continue
+		}
+		if host == entry.Pattern || strings.HasSuffix(host, "."+entry.Pattern) {
+			return entry, true
+		}
+	}
+	return blockEntry{}, false
+}
+
+// 屏蔽原因，用于日志
+func (e blockEntry) String() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("blocked by %s", e.Pattern)
+	}
+	return fmt.Sprintf("blocked by %s since %s: %s", e.Pattern, e.Date, e.Reason)
+}
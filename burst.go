@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// 统计发布（或更新）时间在 window 内的文章数量
+func countRecentItems(feed *gofeed.Feed, window time.Duration) int {
+	if window <= 0 {
+		return 0
+	}
+
+	cutoff := time.Now().Add(-window)
+	count := 0
+	for _, item := range feed.Items {
+		published, err := itemTime(item)
+		if err == nil && published.After(cutoff) {
+			count++
+		}
+	}
+	return count
+}
+
+// 单个 RSS 的上限，RSS 列表中的 burst-limit 选项优先，0 表示不检查
+func feedBurstLimit(config Config, options map[string]string) int {
+	if value, ok := options["burst-limit"]; ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return config.FeedBurstLimit
+}
+
+// 有的 RSS 会突然用新的时间重新发布整个存档，导致通知刷屏。
+// FEED_BURST_WINDOW 内的文章数量超过上限时，本次不发布该 RSS 的文章，并记录到 quarantine.json。
+// 时间窗口过去后自动恢复；经常发文的 RSS 可以用 burst-limit=<数量> 调高上限
+func guardFeedBursts(config Config, store Storage, articles []Article, results []feedResult) []Article {
+	recent := map[string]int{}
+	for _, result := range results {
+		if result.Err == nil {
+			recent[result.URL] = result.RecentItems
+		}
+	}
+
+	var kept []Article
+	var flagged []Article
+	for _, article := range articles {
+		limit := feedBurstLimit(config, article.options)
+		if limit > 0 && recent[article.feedURL] > limit {
+			flagged = append(flagged, article)
+			continue
+		}
+		kept = append(kept, article)
+	}
+	if len(flagged) == 0 {
+		return articles
+	}
+
+	quarantine, err := loadQuarantine(store)
+	if err != nil {
+		logError(store, "Read quarantine error", "err", err)
+	}
+	logged := map[string]bool{}
+	for _, entry := range quarantine {
+		if entry.Provider == "burst" {
+			logged[entry.Link] = true
+		}
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	added := 0
+	for _, article := range flagged {
+		threat := fmt.Sprintf("%d items within %v", recent[article.feedURL], config.FeedBurstWindow)
+		if logged[article.Link] {
+			continue
+		}
+		quarantine = append(quarantine, quarantineEntry{
+			Link:     article.Link,
+			Title:    article.Title,
+			Name:     article.Name,
+			FeedURL:  article.feedURL,
+			Provider: "burst",
+			Threat:   threat,
+			Date:     now,
+		})
+		added++
+		logWarn(store, "Feed burst, not publishing", "feed", article.feedURL, "name", article.Name, "detail", threat)
+	}
+
+	if added > 0 {
+		jsonData, err := json.MarshalIndent(quarantine, "", "  ")
+		if err == nil {
+			err = store.WriteFile("quarantine.json", jsonData)
+		}
+		if err != nil {
+			logError(store, "Write quarantine error", "err", err)
+		}
+	}
+	return kept
+}
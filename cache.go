@@ -0,0 +1,246 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 本地缓存目录，按命名空间（feeds、favicons、fulltext 等）存放数据，多次运行之间共享
+type diskCache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+}
+
+// 缓存条目
+type cacheEntry struct {
+	// 原始 key，例如 RSS 地址
+	Key string `json:"key"`
+	// HTTP 条件请求使用的 ETag
+	ETag string `json:"etag,omitempty"`
+	// HTTP 条件请求使用的 Last-Modified
+	LastModified string `json:"lastModified,omitempty"`
+	// 缓存内容
+	Body []byte `json:"body"`
+	// 写入时间
+	StoredAt time.Time `json:"storedAt"`
+}
+
+// 默认缓存目录，遵循 XDG_CACHE_HOME，例如：~/.cache/grab-latest-rss
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "grab-latest-rss")
+	}
+	return filepath.Join(dir, "grab-latest-rss")
+}
+
+// 打开本地缓存，CACHE_DIR 设置为 off 时禁用缓存并返回 nil
+func openCache(config Config) *diskCache {
+	if config.CacheDir == "off" {
+		return nil
+	}
+
+	if err := os.MkdirAll(config.CacheDir, 0o755); err != nil {
+		fmt.Printf("error creating cache dir %s: %v\n", config.CacheDir, err)
+		return nil
+	}
+
+	return &diskCache{
+		dir:     config.CacheDir,
+		ttl:     config.CacheTTL,
+		maxSize: config.CacheMaxSize,
+	}
+}
+
+// 缓存文件路径，使用 key 的 SHA256 作为文件名
+func (c *diskCache) path(namespace string, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, namespace, hex.EncodeToString(sum[:])+".json")
+}
+
+// 读取缓存，过期或不存在时返回 false
+func (c *diskCache) Get(namespace string, key string) (cacheEntry, bool) {
+	var entry cacheEntry
+	if c == nil {
+		return entry, false
+	}
+
+	data, err := os.ReadFile(c.path(namespace, key))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return entry, false
+	}
+	if c.ttl > 0 && time.Since(entry.StoredAt) > c.ttl {
+		return entry, false
+	}
+
+	return entry, true
+}
+
+// 写入缓存
+func (c *diskCache) Put(namespace string, entry cacheEntry) error {
+	if c == nil {
+		return nil
+	}
+
+	entry.StoredAt = time.Now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(namespace, entry.Key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免并发运行时读到不完整的文件
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// 边下载边写入的缓存条目，内容不在内存中保留。写入同目录下的临时文件，
+// 完整读取后 commit 重命名为缓存文件，中途失败时 abort 删除临时文件
+type cacheWriter struct {
+	f    *os.File
+	body io.WriteCloser
+	path string
+	err  error
+}
+
+// 开始写入缓存，entry.Body 由之后的 Write 提供
+func (c *diskCache) NewWriter(namespace string, entry cacheEntry) (*cacheWriter, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	path := c.path(namespace, entry.Key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	// 同一个 RSS 可能被并发抓取，临时文件名各不相同
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	// 与 Put 写入的 JSON 相同，body 以 base64 编码（与 encoding/json 对 []byte 的编码一致）
+	entry.Body = nil
+	entry.StoredAt = time.Now()
+	header, err := json.Marshal(struct {
+		Key          string    `json:"key"`
+		ETag         string    `json:"etag,omitempty"`
+		LastModified string    `json:"lastModified,omitempty"`
+		StoredAt     time.Time `json:"storedAt"`
+	}{entry.Key, entry.ETag, entry.LastModified, entry.StoredAt})
+	if err == nil {
+		_, err = f.Write(append(header[:len(header)-1], `,"body":"`...))
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &cacheWriter{f: f, body: base64.NewEncoder(base64.StdEncoding, f), path: path}, nil
+}
+
+func (w *cacheWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.body.Write(p)
+	w.err = err
+	return n, err
+}
+
+// 完成写入并替换缓存文件
+func (w *cacheWriter) commit() error {
+	err := w.err
+	if err == nil {
+		err = w.body.Close()
+	}
+	if err == nil {
+		_, err = w.f.Write([]byte(`"}`))
+	}
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(w.f.Name(), w.path)
+	}
+	if err != nil {
+		os.Remove(w.f.Name())
+	}
+	return err
+}
+
+// 放弃写入，删除临时文件
+func (w *cacheWriter) abort() {
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+// 清理缓存：删除超过 TTL 的文件，总大小超过上限时从最旧的文件开始删除
+func (c *diskCache) Evict() error {
+	if c == nil {
+		return nil
+	}
+
+	type cachedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []cachedFile
+	var total int64
+	now := time.Now()
+
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if c.ttl > 0 && now.Sub(info.ModTime()) > c.ttl {
+			return os.Remove(path)
+		}
+		files = append(files, cachedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.maxSize <= 0 || total <= c.maxSize {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			return err
+		}
+		total -= f.size
+	}
+
+	return nil
+}
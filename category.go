@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 按分类分组的文章，写入 rss_grouped.json
+type categoryGroup struct {
+	Name     string    `json:"name"`
+	Articles []Article `json:"articles"`
+}
+
+// 解析 CATEGORIES，格式：tech=example.com,blog.example.org;cycling=lhasa.icu。返回域名 -> 分类和分类的顺序
+func parseCategories(value string) (map[string]string, []string, error) {
+	hosts := map[string]string{}
+	var order []string
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, list, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, nil, fmt.Errorf("invalid category %q, expected name=<domain>,<domain>", entry)
+		}
+		order = append(order, name)
+		for _, host := range strings.Split(list, ",") {
+			host = strings.TrimSpace(host)
+			if host == "" {
+				continue
+			}
+			if !strings.Contains(host, "://") {
+				host = "https://" + host
+			}
+			hosts[normalizedHost(host)] = name
+		}
+	}
+	return hosts, order, nil
+}
+
+// 为没有 category 选项的文章按 CATEGORIES 中的域名设置分类，仍未分类的使用 DEFAULT_CATEGORY
+func assignCategories(config Config, store Storage, articles []Article) {
+	hosts, _, err := parseCategories(config.Categories)
+	if err != nil {
+		logError(store, "Categories error", "err", err)
+	}
+
+	for i := range articles {
+		if articles[i].Category != "" {
+			continue
+		}
+		if category, ok := hosts[normalizedHost(articles[i].DomainName)]; ok {
+			articles[i].Category = category
+		} else if category, ok := hosts[normalizedHost(articles[i].feedURL)]; ok {
+			articles[i].Category = category
+		} else {
+			articles[i].Category = config.DefaultCategory
+		}
+	}
+}
+
+// 按分类分组，分类按 CATEGORIES 中的顺序排列，其余按首次出现的顺序，组内保持原有顺序
+func groupArticles(config Config, articles []Article) []categoryGroup {
+	_, order, _ := parseCategories(config.Categories)
+
+	index := map[string]int{}
+	groups := make([]categoryGroup, 0, len(order))
+	for _, name := range order {
+		if _, ok := index[name]; !ok {
+			index[name] = len(groups)
+			groups = append(groups, categoryGroup{Name: name, Articles: []Article{}})
+		}
+	}
+
+	for _, article := range articles {
+		i, ok := index[article.Category]
+		if !ok {
+			i = len(groups)
+			index[article.Category] = i
+			groups = append(groups, categoryGroup{Name: article.Category, Articles: []Article{}})
+		}
+		groups[i].Articles = append(groups[i].Articles, article)
+	}
+
+	// 去掉没有文章的分类
+	kept := groups[:0]
+	for _, group := range groups {
+		if len(group.Articles) > 0 {
+			kept = append(kept, group)
+		}
+	}
+	return kept
+}
+
+// 写入按分类分组的 rss_grouped.json，前端可以据此按分类显示标签页
+func writeGroupedArticles(config Config, store Storage, articles []Article) error {
+	jsonData, err := json.Marshal(groupArticles(config, articles))
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("rss_grouped.json", jsonData)
+}
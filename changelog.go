@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 上一次运行时友链的快照，保存在 blogroll.json 中，用于生成 CHANGELOG.md
+type blogrollSnapshot struct {
+	// 快照时间，RFC3339
+	Updated string          `json:"updated"`
+	Feeds   []blogrollEntry `json:"feeds"`
+}
+
+// 快照中的一个 RSS
+type blogrollEntry struct {
+	URL        string `json:"url"`
+	Name       string `json:"name,omitempty"`
+	DomainName string `json:"domainName,omitempty"`
+	// 首次出现在列表中的日期
+	Added string `json:"added"`
+	// 退役的日期，见 state=retired
+	Retired string `json:"retired,omitempty"`
+	// 退役时的告别语
+	Farewell string `json:"farewell,omitempty"`
+}
+
+// CHANGELOG.md 中的一条变更
+type blogrollChange struct {
+	text   string
+	reason string
+	// 做出修改的维护者
+	by string
+}
+
+const changelogHeader = "# 友链变更记录\n\n由抓取程序根据 RSS 列表的变化自动生成。\n"
+
+// 比较 RSS 列表、本次抓取结果与上一次的快照，将新增、移除、更换地址、更名、域名变更和退役写入 CHANGELOG.md。
+// 原因和维护者来自 feed_changes.json 中的修改记录（例如升级 HTTPS、feeds 命令）。首次运行只建立快照
+func updateChangelog(store Storage, specs []feedSpec, results []feedResult) error {
+	data, err := store.ReadFile("blogroll.json")
+	if err != nil {
+		return err
+	}
+	var snapshot blogrollSnapshot
+	if data != nil {
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return fmt.Errorf("error parsing blogroll.json: %v", err)
+		}
+	}
+	baseline := data == nil
+	previous := snapshot.Feeds
+
+	old := map[string]blogrollEntry{}
+	for _, entry := range previous {
+		old[entry.URL] = entry
+	}
+	fetched := map[string]feedResult{}
+	for _, result := range results {
+		if result.Err == nil {
+			fetched[result.URL] = result
+		}
+	}
+	recorded, err := recentFeedChanges(store, snapshot.Updated)
+	if err != nil {
+		return err
+	}
+
+	today := localTime().Format("2006-01-02")
+	current := map[string]blogrollEntry{}
+	var added []blogrollEntry
+	var changes []blogrollChange
+	for _, spec := range specs {
+		entry, known := old[spec.URL]
+		if !known {
+			entry = blogrollEntry{URL: spec.URL, Added: today}
+		}
+
+		name, domain := entry.Name, entry.DomainName
+		if result, ok := fetched[spec.URL]; ok {
+			name, domain = result.Name, result.DomainName
+		}
+		if override := cleanText(spec.Options["name"]); override != "" {
+			name = override
+		}
+
+		if known {
+			if entry.Name != "" && name != "" && name != entry.Name {
+				changes = append(changes, blogrollChange{text: fmt.Sprintf("更名：%s → %s", entry.Name, name)})
+			}
+			if entry.DomainName != "" && domain != "" && normalizedHost(domain) != normalizedHost(entry.DomainName) {
+				changes = append(changes, blogrollChange{
+					text:   fmt.Sprintf("域名变更：%s %s → %s", displayName(name, spec.URL), entry.DomainName, domain),
+					reason: recorded[spec.URL].Reason,
+					by:     recorded[spec.URL].By,
+				})
+			}
+		}
+		entry.Name, entry.DomainName = name, domain
+
+		retired := feedRetired(spec.Options)
+		entry.Farewell = ""
+		if retired {
+			entry.Farewell = cleanText(spec.Options["farewell"])
+		}
+		switch {
+		case retired && entry.Retired == "":
+			entry.Retired = today
+			text := "退役：" + feedLabel(name, spec.URL)
+			if entry.Farewell != "" {
+				text += "。告别语：" + entry.Farewell
+			}
+			changes = append(changes, blogrollChange{text: text, reason: recorded[spec.URL].Reason, by: recorded[spec.URL].By})
+		case !retired && entry.Retired != "":
+			entry.Retired = ""
+			changes = append(changes, blogrollChange{text: "恢复：" + feedLabel(name, spec.URL), reason: recorded[spec.URL].Reason, by: recorded[spec.URL].By})
+		}
+		current[spec.URL] = entry
+		if !known {
+			added = append(added, entry)
+		}
+	}
+
+	// 移除的 RSS：与新增的 RSS 主机名或博客名相同时视为更换地址
+	for _, entry := range previous {
+		if _, ok := current[entry.URL]; ok {
+			continue
+		}
+		moved := -1
+		for i, a := range added {
+			if normalizedHost(a.URL) == normalizedHost(entry.URL) || (entry.Name != "" && a.Name == entry.Name) {
+				moved = i
+				break
+			}
+		}
+		if moved < 0 {
+			changes = append(changes, blogrollChange{text: "移除：" + feedLabel(entry.Name, entry.URL), reason: recorded[entry.URL].Reason, by: recorded[entry.URL].By})
+			continue
+		}
+
+		a := added[moved]
+		added = append(added[:moved], added[moved+1:]...)
+		// 沿用原来的加入日期
+		a.Added = entry.Added
+		current[a.URL] = a
+		change, ok := recorded[entry.URL]
+		if !ok {
+			change = recorded[a.URL]
+		}
+		changes = append(changes, blogrollChange{text: fmt.Sprintf("更换 RSS 地址：%s %s → %s", displayName(a.Name, a.URL), entry.URL, a.URL), reason: change.Reason, by: change.By})
+	}
+	for _, a := range added {
+		changes = append(changes, blogrollChange{text: "新增：" + feedLabel(a.Name, a.URL), reason: recorded[a.URL].Reason, by: recorded[a.URL].By})
+	}
+
+	snapshot = blogrollSnapshot{Updated: time.Now().Format(time.RFC3339), Feeds: make([]blogrollEntry, 0, len(current))}
+	for _, entry := range current {
+		snapshot.Feeds = append(snapshot.Feeds, entry)
+	}
+	sort.Slice(snapshot.Feeds, func(i, j int) bool { return snapshot.Feeds[i].URL < snapshot.Feeds[j].URL })
+	jsonData, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if baseline {
+		changes = []blogrollChange{{text: fmt.Sprintf("开始记录，当前共 %d 个博客", len(snapshot.Feeds))}}
+	}
+	if len(changes) > 0 {
+		if err := prependChangelog(store, today, changes); err != nil {
+			return err
+		}
+	}
+	return store.WriteFile("blogroll.json", jsonData)
+}
+
+// 博客名，没有时使用地址
+func displayName(name string, feedURL string) string {
+	if name != "" {
+		return name
+	}
+	return feedURL
+}
+
+// 博客名和地址，没有博客名时只有地址
+func feedLabel(name string, feedURL string) string {
+	if name != "" {
+		return name + "（" + feedURL + "）"
+	}
+	return feedURL
+}
+
+// feed_changes.json 中 since 之后各地址最近一次修改的记录，新旧地址都可以查到
+func recentFeedChanges(store Storage, since string) (map[string]feedChange, error) {
+	data, err := store.ReadFile("feed_changes.json")
+	if err != nil || data == nil {
+		return map[string]feedChange{}, err
+	}
+	var log []feedChange
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("error parsing feed_changes.json: %v", err)
+	}
+
+	recorded := map[string]feedChange{}
+	// 最新的在前，保留每个地址最近的一条
+	for _, change := range log {
+		if change.Date < since {
+			break
+		}
+		for _, u := range []string{change.URL, change.NewURL} {
+			if _, ok := recorded[u]; !ok && u != "" {
+				recorded[u] = change
+			}
+		}
+	}
+	return recorded, nil
+}
+
+// 将变更写在 CHANGELOG.md 最前面，当天已有记录时并入当天
+func prependChangelog(store Storage, date string, changes []blogrollChange) error {
+	data, err := store.ReadFile("CHANGELOG.md")
+	if err != nil {
+		return err
+	}
+	body := strings.TrimPrefix(string(data), changelogHeader)
+	body = strings.TrimLeft(body, "\n")
+
+	var b strings.Builder
+	for _, change := range changes {
+		b.WriteString("- " + change.text)
+		if change.reason != "" {
+			b.WriteString("。原因：" + change.reason)
+		}
+		if change.by != "" {
+			b.WriteString("。维护者：" + change.by)
+		}
+		b.WriteString("\n")
+	}
+
+	heading := "## " + date + "\n\n"
+	if strings.HasPrefix(body, heading) {
+		body = heading + b.String() + strings.TrimPrefix(body, heading)
+	} else {
+		if body != "" {
+			body = "\n" + body
+		}
+		body = heading + b.String() + body
+	}
+	return store.WriteFile("CHANGELOG.md", []byte(changelogHeader+"\n"+body))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RSS 列表的一次自动修改，记录在 feed_changes.json 中
+type feedChange struct {
+	// 修改时间，RFC3339
+	Date string `json:"date"`
+	// 修改类型，例如 https-upgrade
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	NewURL string `json:"newUrl,omitempty"`
+	// 修改原因
+	Reason string `json:"reason,omitempty"`
+	// 做出修改的维护者，见 MAINTAINER
+	By string `json:"by,omitempty"`
+}
+
+// 追加记录到 feed_changes.json，最新的在前
+func recordFeedChanges(store Storage, changes []feedChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	data, err := store.ReadFile("feed_changes.json")
+	if err != nil {
+		return err
+	}
+
+	var log []feedChange
+	if data != nil {
+		if err := json.Unmarshal(data, &log); err != nil {
+			return fmt.Errorf("error parsing feed_changes.json: %v", err)
+		}
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	for i := range changes {
+		if changes[i].Date == "" {
+			changes[i].Date = now
+		}
+		if changes[i].By == "" {
+			changes[i].By = currentMaintainer
+		}
+	}
+	log = append(changes, log...)
+
+	jsonData, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("feed_changes.json", jsonData)
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 子命令
+type command struct {
+	name  string
+	usage string
+	// 是否需要存储后端
+	needsStore bool
+	// 是否支持 -dry-run
+	dryRun bool
+	run    func(config Config, store Storage, args []string) error
+}
+
+var commands = []command{
+	{
+		name:       "fetch",
+		usage:      "fetch all feeds and publish the latest articles (default)",
+		needsStore: true,
+		dryRun:     true,
+		run: func(config Config, store Storage, args []string) error {
+			return runFetch(config, store)
+		},
+	},
+	{
+		name:       "daemon",
+		usage:      "keep running and fetch feeds on the tier schedules",
+		needsStore: true,
+		run: func(config Config, store Storage, args []string) error {
+			return runDaemon(config, store)
+		},
+	},
+	{
+		name:       "serve",
+		usage:      "run the daemon and serve the latest articles over HTTP: serve [-addr :8080]",
+		needsStore: true,
+		run: func(config Config, store Storage, args []string) error {
+			fs := flag.NewFlagSet("serve", flag.ExitOnError)
+			fs.StringVar(&config.ServeAddr, "addr", getEnvDefault("SERVE_ADDR", ":8080"), "listen address")
+			fs.Parse(args)
+			return runDaemon(config, store)
+		},
+	},
+	{
+		name:       "preview",
+		usage:      "run read-only against another feed list and print the result: preview -feeds <file>",
+		needsStore: true,
+		run: func(config Config, store Storage, args []string) error {
+			fs := flag.NewFlagSet("preview", flag.ExitOnError)
+			feedsFile := fs.String("feeds", "", "feed list to evaluate")
+			fs.Parse(args)
+			if *feedsFile == "" {
+				return fmt.Errorf("usage: preview -feeds <file>")
+			}
+			return runPreview(config, store, *feedsFile)
+		},
+	},
+	{
+		name:       "validate",
+		usage:      "check that every feed is reachable, parses and has dated items",
+		needsStore: true,
+		run: func(config Config, store Storage, args []string) error {
+			return runValidate(store)
+		},
+	},
+	{
+		name:       "feeds",
+		usage:      "edit the feed list: feeds add|remove|replace|set <url> [...]",
+		needsStore: true,
+		dryRun:     true,
+		run: func(config Config, store Storage, args []string) error {
+			return runFeedsCommand(store, args)
+		},
+	},
+	{
+		name:       "suggest",
+		usage:      "suggest new blogs from friends' blogrolls",
+		needsStore: true,
+		dryRun:     true,
+		run: func(config Config, store Storage, args []string) error {
+			return runSuggest(config, store)
+		},
+	},
+	{
+		name:       "notify",
+		usage:      "re-send notifications from history: notify replay -since <date> [-channel telegram|webhook]",
+		needsStore: true,
+		run: func(config Config, store Storage, args []string) error {
+			return runNotifyCommand(config, store, args)
+		},
+	},
+	{
+		name:       "export-opml",
+		usage:      "export the feed list as OPML: export-opml [-o file]",
+		needsStore: true,
+		run: func(config Config, store Storage, args []string) error {
+			fs := flag.NewFlagSet("export-opml", flag.ExitOnError)
+			output := fs.String("o", "", "write to this file instead of stdout")
+			fs.Parse(args)
+
+			if *output == "" {
+				return exportOPML(store, os.Stdout)
+			}
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			if err := exportOPML(store, f); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		},
+	},
+	{
+		name:       "audit",
+		usage:      "check the audit trail: audit verify | audit find <sha256 of rss_data.json>",
+		needsStore: true,
+		run: func(config Config, store Storage, args []string) error {
+			return runAuditCommand(config, store, args)
+		},
+	},
+	{
+		name:       "issue-command",
+		usage:      "run /feeds and /fetch commands from a maintainer's issue comment (GITHUB_EVENT_PATH)",
+		needsStore: true,
+		dryRun:     true,
+		run: func(config Config, store Storage, args []string) error {
+			return runIssueCommand(config, store)
+		},
+	},
+	{
+		name:  "state",
+		usage: "migrate local state and history: state export|import <file.tar.gz>",
+		run: func(config Config, store Storage, args []string) error {
+			return runStateCommand(config, store, args)
+		},
+	},
+}
+
+// 输出用法
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command] [args]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// 读取 KEY=VALUE 格式的配置文件并设置为环境变量，已设置的环境变量优先
+func loadEnvFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+		}
+	}
+	return scanner.Err()
+}
+
+func main() {
+	configFile := flag.String("config", "", "load environment variables from this KEY=VALUE file")
+	profile := flag.String("profile", "", "apply a configuration profile: local, ci or the name of a .env.<name> file (overrides PROFILE)")
+	backend := flag.String("backend", "", "storage backend: github, cos, s3, local, checkout, sftp, webdav, gist or none, several separated by commas (overrides STORAGE_BACKEND)")
+	serveAddr := flag.String("serve", "", "serve the latest articles over HTTP at this address, e.g. :8080 (same as the serve command)")
+	dryRun := flag.Bool("dry-run", false, "run without writing to the storage backend and print what would be written")
+	allowEmpty := flag.Bool("allow-empty", false, "publish even when no articles were fetched, replacing the previous data (same as ALLOW_EMPTY_PUBLISH=true)")
+	flag.BoolVar(&verbose, "v", false, "print per-feed details")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *configFile != "" {
+		if err := loadEnvFile(*configFile); err != nil {
+			fmt.Printf("Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *profile == "" {
+		*profile = os.Getenv("PROFILE")
+	}
+	if *profile != "" {
+		if err := applyProfile(*profile); err != nil {
+			fmt.Printf("Error loading profile: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	config := initConfig()
+	if *backend != "" {
+		config.StorageBackend = *backend
+	}
+	if *serveAddr != "" {
+		config.ServeAddr = *serveAddr
+	}
+	if *allowEmpty {
+		config.AllowEmptyPublish = true
+	}
+	if err := setupLogging(config); err != nil {
+		fmt.Printf("Error configuring logging: %v\n", err)
+		os.Exit(1)
+	}
+	defer reportPanic()
+	if err := configureCrawler(config); err != nil {
+		fmt.Printf("Error configuring HTTP client: %v\n", err)
+		os.Exit(1)
+	}
+	if err := configureMaintainer(config); err != nil {
+		fmt.Printf("Error configuring maintainer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := configureRender(config); err != nil {
+		fmt.Printf("Error configuring render service: %v\n", err)
+		os.Exit(1)
+	}
+	if err := configureArticleID(config); err != nil {
+		fmt.Printf("Error configuring article IDs: %v\n", err)
+		os.Exit(1)
+	}
+	if err := configureDates(config); err != nil {
+		fmt.Printf("Error configuring dates: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 默认执行 fetch，设置了监听地址时以常驻模式运行
+	args := flag.Args()
+	name := "fetch"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	} else if config.ServeAddr != "" {
+		name = "daemon"
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Printf("Unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	// 根据 STORAGE_BACKEND 选择存储后端
+	var store Storage
+	if cmd.needsStore {
+		var err error
+		store, err = newStorage(config)
+		if err != nil {
+			fmt.Printf("Error creating storage backend: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 写入只保存在内存中，运行结束后输出
+	var dry *readOnlyStorage
+	if *dryRun {
+		if !cmd.dryRun {
+			fmt.Printf("-dry-run is not supported by %s\n", name)
+			os.Exit(2)
+		}
+		dry = newReadOnlyStorage(store, nil)
+		store = dry
+		config = readOnlyConfig(config)
+		// 与通知一样，试运行时不上报错误
+		reporter = nil
+	}
+
+	err := cmd.run(config, store, args)
+	flushLogs()
+	if err != nil {
+		fmt.Printf("Error running %s: %v\n", name, err)
+		os.Exit(1)
+	}
+
+	if dry != nil {
+		if err := dry.printWrites(); err != nil {
+			fmt.Printf("Error printing dry-run output: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	// 存储后端：github、cos、s3、none，多个后端用逗号分隔
+	StorageBackend string
+	// 使用多个后端时发布后比较各后端的 rss_data.json
+	VerifyCopies bool
+	// 本地 RSS 列表文件，供不自带 RSS 列表的后端使用
+	FeedsFile string
+
+	GithubToken         string
+	GithubName          string
+	GithubRepository    string
+	GithubBranch        string
+	GithubDir           string
+	GithubFeedsFile     string
+	GithubDataFile      string
+	GithubAuthorName    string
+	GithubAuthorEmail   string
+	Maintainer          string
+	Maintainers         string
+	GithubSingleCommit  bool
+	FeedsPullRequest    bool
+	GithubCallEstimate  int
+	GithubCallReserve   int
+	GithubRateLimitWait time.Duration
+
+	CosBucketURL string
+	SecretID     string
+	SecretKey    string
+
+	CosPrefix       string
+	CosCacheControl string
+	CosFeedsKey     string
+
+	CDNPurgeURL   string
+	CDNPurgeFiles string
+	CDNSecretID   string
+	CDNSecretKey  string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+
+	OutputDir string
+
+	CheckoutDir string
+
+	SFTPAddr       string
+	SFTPUser       string
+	SFTPPassword   string
+	SFTPKeyFile    string
+	SFTPHostKey    string
+	SFTPKnownHosts string
+	SFTPDir        string
+
+	WebDAVURL       string
+	WebDAVUser      string
+	WebDAVPassword  string
+	WebDAVFeedsFile string
+
+	GistID    string
+	GistToken string
+
+	IPFSProvider string
+	IPFSAPI      string
+	IPFSToken    string
+	IPFSGateway  string
+
+	DNSBeaconProvider string
+	DNSBeaconName     string
+	DNSBeaconZone     string
+	DNSBeaconToken    string
+
+	CacheDir     string
+	CacheTTL     time.Duration
+	CacheMaxSize int64
+
+	Tiers          string
+	DaemonSchedule string
+
+	FetchConcurrency  int
+	FetchTimeout      time.Duration
+	RenderService     string
+	RenderTimeout     time.Duration
+	EnrichConcurrency int
+	EnrichTimeout     time.Duration
+	Avatars           bool
+	AvatarRefresh     time.Duration
+	SummaryLength     int
+	DateFallback      string
+	DateFormat        string
+	DateLocale        string
+	TimeZone          string
+	CoverFromPage     bool
+	CoverRehost       bool
+
+	ServeAddr       string
+	ServeMaxAge     time.Duration
+	ServeCORSOrigin string
+	ServeRateLimit  int
+	ServeRateBurst  int
+	MetricsAddr     string
+
+	Categories      string
+	DefaultCategory string
+	GroupedOutput   bool
+	LiteOutput      bool
+	LiteTextLength  int
+	CSVOutput       bool
+	CSVExcel        bool
+
+	ArchivePages     bool
+	FriendsFeed      bool
+	FriendsJSONFeed  bool
+	FriendsPage      bool
+	FriendsFeedTitle string
+	FeedBaseURL      string
+	Badges           bool
+	ArchiveJSON      bool
+	ArchiveRetention time.Duration
+	SiteLanguage     string
+
+	SuggestMinScore int
+
+	DisableAfterFailures int
+	RecheckInterval      time.Duration
+	HTTPSProbeInterval   time.Duration
+
+	TelegramBotToken  string
+	TelegramChatID    string
+	TelegramParseMode string
+
+	WebhookURLs string
+
+	NotifyTemplates string
+	NotifyLanguage  string
+
+	HistoryRetention time.Duration
+	AuditLog         bool
+	AuditKey         string
+	ArticleID        string
+
+	FeedBurstLimit  int
+	FeedBurstWindow time.Duration
+
+	AllowEmptyPublish bool
+
+	SafetyCheck     string
+	SafeBrowsingKey string
+	URLhausKey      string
+
+	LogLevel       string
+	LogFormat      string
+	LogFile        string
+	LogRemoteLevel string
+
+	ActionsAnnotations bool
+
+	ErrorLogMaxSize  int64
+	ErrorLogRotate   string
+	ErrorLogKeepSize int64
+
+	UserAgent       string
+	RespectRobots   bool
+	FetchProxy      string
+	MaxResponseSize int64
+
+	SentryDSN         string
+	SentryEnvironment string
+	ErrorReportURL    string
+	ErrorReportLevel  string
+}
+
+func initConfig() Config {
+	return Config{
+		// 存储后端，默认 GitHub
+		StorageBackend: getEnvDefault("STORAGE_BACKEND", "github"),
+		// 使用多个后端（例如 github,cos）时，发布后比较各后端的 rss_data.json，不一致时提醒
+		VerifyCopies: getEnvBool("VERIFY_COPIES", true),
+		// 本地 RSS 列表文件
+		FeedsFile: getEnvDefault("FEEDS_FILE", "rss_feeds.txt"),
+
+		// GitHub API 令牌
+		GithubToken: os.Getenv("TOKEN"),
+		// GitHub 用户名或组织名
+		GithubName: getEnvDefault("GITHUB_OWNER", "achuanya"),
+		// GitHub 仓库名
+		GithubRepository: getEnvDefault("GITHUB_REPO", "lhasa.github.io"),
+		// 读写的分支
+		GithubBranch: getEnvDefault("GITHUB_BRANCH", "master"),
+		// 数据目录，为空表示仓库根目录
+		GithubDir: getEnvDefault("GITHUB_DIR", "api"),
+		// 数据目录中 RSS 列表的文件名
+		GithubFeedsFile: getEnvDefault("GITHUB_FEEDS_FILE", "rss_feeds.txt"),
+		// 数据目录中文章数据的文件名
+		GithubDataFile: getEnvDefault("GITHUB_DATA_FILE", "rss_data.json"),
+		// 提交的作者和提交者，为空时使用 TOKEN 对应的账号
+		GithubAuthorName: os.Getenv("GITHUB_AUTHOR_NAME"),
+		// 提交者的邮箱，只设置名称为 github-actions[bot] 时使用 GitHub Actions 机器人的邮箱
+		GithubAuthorEmail: os.Getenv("GITHUB_AUTHOR_EMAIL"),
+		// 当前操作的维护者，署名写入提交信息、CHANGELOG.md 和 audit.log，例如在 workflow 中设置为 ${{ github.actor }}
+		Maintainer: os.Getenv("MAINTAINER"),
+		// 逗号分隔的维护者名单（GitHub 用户名），设置后 MAINTAINER 和 Issue 命令的评论者必须在名单中
+		Maintainers: os.Getenv("MAINTAINERS"),
+		// 一次运行写入的所有文件合并为一次提交，关闭时每个文件单独提交
+		GithubSingleCommit: getEnvBool("GITHUB_SINGLE_COMMIT", true),
+		// 自动修改 RSS 列表（升级 HTTPS、feeds 命令等）时提交 Pull Request 而不是直接提交到 GITHUB_BRANCH，只对 GitHub 后端有效
+		FeedsPullRequest: getEnvBool("FEEDS_PULL_REQUEST", false),
+		// 首次运行时预计的 API 调用次数，之后使用上一次运行的实际次数
+		GithubCallEstimate: int(getEnvInt64("GITHUB_API_ESTIMATE", 30)),
+		// 保留的 API 配额，剩余配额低于该值时跳过日志和统计文件的写入
+		GithubCallReserve: int(getEnvInt64("GITHUB_API_RESERVE", 100)),
+		// 遇到限流时最多等待的时间，需要等待更久时直接报错
+		GithubRateLimitWait: getEnvDuration("GITHUB_RATE_LIMIT_WAIT", 2*time.Minute),
+
+		// Tencent COS
+		CosBucketURL: "https://cos.lhasa.icu",
+		// Tencent SecretID
+		SecretID: os.Getenv("COS_SECRET_ID"),
+		// Tencent SecretKey
+		SecretKey: os.Getenv("COS_SECRET_KEY"),
+		// 数据文件在存储桶中的目录，/ 表示存储桶根目录
+		CosPrefix: cosPrefix(getEnvDefault("COS_PREFIX", "rss/")),
+		// 上传时设置的 Cache-Control，例如 max-age=300，为空时不设置
+		CosCacheControl: os.Getenv("COS_CACHE_CONTROL"),
+		// 存储桶数据目录中 RSS 列表的文件名，例如 rss_feeds.txt 或 feeds.opml，为空时读取本地 FEEDS_FILE
+		CosFeedsKey: os.Getenv("COS_FEEDS_KEY"),
+		// COS 存储桶 COS_PREFIX 目录在腾讯云 CDN 上的地址，例如 https://cdn.lhasa.icu/rss/，设置后上传完成时刷新 CDN 缓存
+		CDNPurgeURL: os.Getenv("COS_CDN_URL"),
+		// 需要刷新缓存的文件，逗号分隔，支持通配符，例如 rss_data.json,archive/*
+		CDNPurgeFiles: getEnvDefault("COS_CDN_PURGE", "rss_data.json"),
+		// 刷新 CDN 缓存使用的密钥，需要 CDN 刷新权限，为空时使用 COS_SECRET_ID、COS_SECRET_KEY
+		CDNSecretID:  os.Getenv("CDN_SECRET_ID"),
+		CDNSecretKey: os.Getenv("CDN_SECRET_KEY"),
+
+		// S3 兼容存储，例如：https://<account>.r2.cloudflarestorage.com
+		S3Endpoint: os.Getenv("S3_ENDPOINT"),
+		// 区域，R2 使用 auto
+		S3Region: getEnvDefault("S3_REGION", "auto"),
+		// 存储桶名称
+		S3Bucket: os.Getenv("S3_BUCKET"),
+		// Access Key ID
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+		// Secret Access Key
+		S3SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+
+		// 本地存储后端的输出目录
+		OutputDir: getEnvDefault("OUTPUT_DIR", "public"),
+		// checkout 后端读写的仓库工作区，默认为 GitHub Actions 的 GITHUB_WORKSPACE，不在 Actions 中时为当前目录
+		CheckoutDir: getEnvDefault("CHECKOUT_DIR", getEnvDefault("GITHUB_WORKSPACE", ".")),
+
+		// SFTP 服务器，例如：example.com 或 example.com:2222
+		SFTPAddr: os.Getenv("SFTP_ADDR"),
+		SFTPUser: os.Getenv("SFTP_USER"),
+		// 密码和私钥至少设置一个
+		SFTPPassword: os.Getenv("SFTP_PASSWORD"),
+		SFTPKeyFile:  os.Getenv("SFTP_KEY_FILE"),
+		// 服务器主机密钥的 SHA256 指纹，未设置时使用 SFTP_KNOWN_HOSTS（默认 ~/.ssh/known_hosts）校验
+		SFTPHostKey:    os.Getenv("SFTP_HOST_KEY"),
+		SFTPKnownHosts: os.Getenv("SFTP_KNOWN_HOSTS"),
+		// 远程数据目录，相对路径从登录目录开始
+		SFTPDir: getEnvDefault("SFTP_DIR", "rss"),
+
+		// WebDAV 数据目录的地址，例如 Nextcloud：https://cloud.example.com/remote.php/dav/files/<user>/rss/
+		WebDAVURL:      os.Getenv("WEBDAV_URL"),
+		WebDAVUser:     os.Getenv("WEBDAV_USER"),
+		WebDAVPassword: os.Getenv("WEBDAV_PASSWORD"),
+		// WebDAV 数据目录中的 RSS 列表，例如 rss_feeds.txt，为空时读取本地 FEEDS_FILE
+		WebDAVFeedsFile: os.Getenv("WEBDAV_FEEDS_FILE"),
+
+		// 保存数据的 Gist ID，为空时第一次写入会创建一个私密 Gist
+		GistID: os.Getenv("GIST_ID"),
+		// 需要 gist 权限的 Token，为空时使用 TOKEN
+		GistToken: os.Getenv("GIST_TOKEN"),
+
+		// 发布到 IPFS 的固定服务：kubo（Kubo RPC API，也适用于 Filebase 等兼容服务）、pinata，默认不发布
+		IPFSProvider: os.Getenv("IPFS_PROVIDER"),
+		// Kubo RPC API 地址
+		IPFSAPI: getEnvDefault("IPFS_API", "http://127.0.0.1:5001"),
+		// 固定服务的令牌，Pinata 为 JWT
+		IPFSToken: os.Getenv("IPFS_TOKEN"),
+		// manifest.json 中访问地址使用的网关
+		IPFSGateway: getEnvDefault("IPFS_GATEWAY", "https://ipfs.io/ipfs/"),
+
+		// 发布成功后更新的 DNS TXT 记录：cloudflare、dnspod，默认不更新
+		DNSBeaconProvider: os.Getenv("DNS_BEACON_PROVIDER"),
+		// 记录的完整名称，例如：_rss.lhasa.icu
+		DNSBeaconName: os.Getenv("DNS_BEACON_NAME"),
+		// Cloudflare 为 Zone ID，DNSPod 为主域名
+		DNSBeaconZone: os.Getenv("DNS_BEACON_ZONE"),
+		// Cloudflare 为 API Token，DNSPod 为 "ID,Token"
+		DNSBeaconToken: os.Getenv("DNS_BEACON_TOKEN"),
+
+		// 本地缓存目录，设置为 off 禁用缓存
+		CacheDir: getEnvDefault("CACHE_DIR", defaultCacheDir()),
+		// 缓存有效期，默认 7 天
+		CacheTTL: getEnvDuration("CACHE_TTL", 7*24*time.Hour),
+		// 缓存总大小上限，默认 100 MB
+		CacheMaxSize: getEnvInt64("CACHE_MAX_SIZE_MB", 100) << 20,
+
+		// 同时抓取的 RSS 数量
+		FetchConcurrency: int(getEnvInt64("FETCH_CONCURRENCY", 8)),
+		// 抓取阶段的时间预算，用完后不再开始新的抓取，0 表示不限制
+		FetchTimeout: getEnvDuration("FETCH_TIMEOUT", 0),
+		// 无头浏览器渲染服务的地址，只用于 RSS 列表中设置了 render=true 的 RSS。
+		// 含有 {url} 时替换为 RSS 地址后 GET，否则 POST {"url": ...}
+		RenderService: os.Getenv("RENDER_SERVICE"),
+		// 渲染单个 RSS 的超时时间
+		RenderTimeout: getEnvDuration("RENDER_TIMEOUT", time.Minute),
+		// 补充文章信息（全文、头像等）的并发数，低于抓取以免影响核心数据
+		EnrichConcurrency: int(getEnvInt64("ENRICH_CONCURRENCY", 2)),
+		// 补充文章信息的时间预算，超时后未处理的文章保持原样
+		EnrichTimeout: getEnvDuration("ENRICH_TIMEOUT", 2*time.Minute),
+		// 抓取博客头像并缓存到数据目录 avatars/
+		Avatars: getEnvBool("AVATARS", false),
+		// 已缓存的头像多久重新获取一次
+		AvatarRefresh: getEnvDuration("AVATAR_REFRESH", 30*24*time.Hour),
+		// 文章摘要的最大字数，0 表示不生成摘要
+		SummaryLength: int(getEnvInt64("SUMMARY_LENGTH", 200)),
+		// 文章时间无法解析时的处理：now 使用当前时间，feed 使用 RSS 的更新时间，skip 不发布该文章
+		DateFallback: getEnvDefault("DATE_FALLBACK", "now"),
+		// rss_data.json 中 date 字段的格式，Go 的时间格式或 relative（例如 3 天前），为空时按 DATE_LOCALE 选择
+		DateFormat: os.Getenv("DATE_FORMAT"),
+		// date 字段的语言：en、zh-CN，zh-CN 时月份和星期使用中文
+		DateLocale: getEnvDefault("DATE_LOCALE", "en"),
+		// 日志时间、文章日期和调度表使用的时区，IANA 名称，例如 America/New_York，为空时使用 UTC+8
+		TimeZone: os.Getenv("TIMEZONE"),
+		// RSS 中没有图片时，从文章页面的 og:image 获取封面
+		CoverFromPage: getEnvBool("COVER_FROM_PAGE", false),
+		// 将封面转存到数据目录 covers/
+		CoverRehost: getEnvBool("COVER_REHOST", false),
+
+		// 常驻进程的分组调度表，例如：friends=*/30 * * * *;acquaintances=0 */6 * * *;archives=@daily
+		Tiers: os.Getenv("TIERS"),
+		// 未分组 RSS 的调度
+		DaemonSchedule: getEnvDefault("DAEMON_SCHEDULE", "@hourly"),
+
+		// HTTP 服务监听地址，也可以通过 --serve 指定
+		ServeAddr: os.Getenv("SERVE_ADDR"),
+		// /api/rss 的 Cache-Control max-age
+		ServeMaxAge: getEnvDuration("SERVE_MAX_AGE", 5*time.Minute),
+		// 允许跨域访问的来源
+		ServeCORSOrigin: getEnvDefault("SERVE_CORS_ORIGIN", "*"),
+		// 每个 IP 每分钟最多请求 /api/rss 的次数，0 表示不限制
+		ServeRateLimit: int(getEnvInt64("SERVE_RATE_LIMIT", 120)),
+		// 每个 IP 允许的突发请求数
+		ServeRateBurst: int(getEnvInt64("SERVE_RATE_BURST", 30)),
+		// 常驻模式下单独暴露 /metrics 的地址，--serve 时 /metrics 也会挂在同一个服务上
+		MetricsAddr: os.Getenv("METRICS_ADDR"),
+
+		// 按域名分类，例如：tech=example.com,blog.example.org;cycling=lhasa.icu，RSS 列表中的 category 选项优先
+		Categories: os.Getenv("CATEGORIES"),
+		// 未分类的博客所属的分类
+		DefaultCategory: os.Getenv("DEFAULT_CATEGORY"),
+		// 同时生成按分类分组的 rss_grouped.json
+		GroupedOutput: getEnvBool("GROUPED_OUTPUT", false),
+		// 同时生成只有名称、标题、链接和日期的 rss_lite.json，供移动端小组件使用
+		LiteOutput: getEnvBool("LITE_OUTPUT", false),
+		// rss_lite.json 中名称和标题的最大字符数，0 表示不截断
+		LiteTextLength: int(getEnvInt64("LITE_TEXT_LENGTH", 40)),
+		// 同时生成 rss_data.csv，可以用表格软件打开
+		CSVOutput: getEnvBool("CSV_OUTPUT", false),
+		// rss_data.csv 加上 UTF-8 BOM 并使用 CRLF 换行，便于 Excel、WPS 直接打开
+		CSVExcel: getEnvBool("CSV_EXCEL", false),
+
+		// 为每篇新文章生成存档页 archive/<id>.html
+		ArchivePages: getEnvBool("ARCHIVE_PAGES", false),
+		// 将所有朋友的最新文章合并为 Atom 订阅 friends.xml
+		FriendsFeed: getEnvBool("FRIENDS_FEED", false),
+		// 同时生成 JSON Feed 1.1 格式的 friends-feed.json
+		FriendsJSONFeed: getEnvBool("FRIENDS_JSON_FEED", false),
+		// 生成静态页面 friends.html
+		FriendsPage: getEnvBool("FRIENDS_HTML", false),
+		// 合并订阅和 friends.html 的标题
+		FriendsFeedTitle: getEnvDefault("FRIENDS_FEED_TITLE", "朋友们的最新文章"),
+		// 合并订阅发布后的公开地址前缀，例如 https://lhasa.icu/api/，用于订阅中的 self 链接
+		FeedBaseURL: os.Getenv("FEED_BASE_URL"),
+		// 生成 RSS 状态徽章 badges/*.svg
+		Badges: getEnvBool("BADGES", false),
+		// 累积所有抓取过的文章，写入 archive.json
+		ArchiveJSON: getEnvBool("ARCHIVE_JSON", false),
+		// archive.json 的保留期限（按发布时间），0 表示永久保留
+		ArchiveRetention: getEnvDuration("ARCHIVE_RETENTION", 0),
+		// 生成的 HTML 页面的语言
+		SiteLanguage: getEnvDefault("SITE_LANGUAGE", "zh-CN"),
+
+		// 推荐博客至少被几位朋友链接
+		SuggestMinScore: int(getEnvInt64("SUGGEST_MIN_SCORE", 1)),
+
+		// 连续失败多少次后自动停用 RSS，0 表示不停用
+		DisableAfterFailures: int(getEnvInt64("DISABLE_AFTER_FAILURES", 10)),
+		// 停用的 RSS 多久复查一次
+		RecheckInterval: getEnvDuration("RECHECK_INTERVAL", 24*time.Hour),
+		// http:// 的 RSS 多久尝试一次 https://，0 表示不尝试
+		HTTPSProbeInterval: getEnvDuration("HTTPS_PROBE_INTERVAL", 7*24*time.Hour),
+
+		// Telegram 机器人令牌，与 TELEGRAM_CHAT_ID 同时设置时发送运行通知
+		TelegramBotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+		// 接收通知的会话 ID
+		TelegramChatID: os.Getenv("TELEGRAM_CHAT_ID"),
+		// Telegram 消息格式：MarkdownV2、HTML，默认纯文本
+		TelegramParseMode: os.Getenv("TELEGRAM_PARSE_MODE"),
+
+		// 新文章出现时 POST 通知的地址，多个地址用逗号分隔
+		WebhookURLs: os.Getenv("WEBHOOK_URLS"),
+
+		// 通知模板目录，按渠道放置 telegram.tmpl、alert.tmpl、webhook.tmpl 等，未提供的使用内置模板
+		NotifyTemplates: os.Getenv("NOTIFY_TEMPLATES"),
+		// 内置通知模板的语言：zh-CN、en
+		NotifyLanguage: getEnvDefault("NOTIFY_LANGUAGE", "zh-CN"),
+
+		// history.json 中记录的保留期限，默认一年，0 表示永久保留
+		HistoryRetention: getEnvDuration("HISTORY_RETENTION", 365*24*time.Hour),
+		// 每次发布后在 audit.log 中追加一条哈希链记录
+		AuditLog: getEnvBool("AUDIT_LOG", false),
+		// 签名审计记录的密钥，为空时只有哈希链
+		AuditKey: os.Getenv("AUDIT_KEY"),
+		// 识别同一篇文章的方式：guid、link、link-no-query、title，可以在 RSS 列表中用 id 选项单独设置
+		ArticleID: getEnvDefault("ARTICLE_ID", "guid"),
+
+		// 单个 RSS 在 FEED_BURST_WINDOW 内最多出现多少篇文章，超过时视为重新发布了整个存档，0 表示不检查
+		FeedBurstLimit: int(getEnvInt64("FEED_BURST_LIMIT", 10)),
+		// 统计文章数量的时间窗口
+		FeedBurstWindow: getEnvDuration("FEED_BURST_WINDOW", 24*time.Hour),
+
+		// 本次没有任何文章时仍然覆盖已发布的数据，默认保留上一次的 rss_data.json
+		AllowEmptyPublish: getEnvBool("ALLOW_EMPTY_PUBLISH", false),
+
+		// 发布前检查新文章链接的服务，多个用逗号分隔：safebrowsing、urlhaus
+		SafetyCheck: os.Getenv("SAFETY_CHECK"),
+		// Google Safe Browsing API key
+		SafeBrowsingKey: os.Getenv("SAFE_BROWSING_API_KEY"),
+		// URLhaus Auth-Key
+		URLhausKey: os.Getenv("URLHAUS_AUTH_KEY"),
+
+		// 标准输出和日志文件的级别：debug、info、warn、error、off，-v 时为 debug
+		LogLevel: getEnvDefault("LOG_LEVEL", "info"),
+		// 日志格式：text、json
+		LogFormat: getEnvDefault("LOG_FORMAT", "text"),
+		// 同时追加写入的本地日志文件，默认不写入
+		LogFile: os.Getenv("LOG_FILE"),
+		// 写入存储后端 error.log 的级别，off 表示不写入
+		LogRemoteLevel: getEnvDefault("LOG_REMOTE_LEVEL", "warn"),
+		// 警告和错误同时输出为 GitHub Actions 注释，默认在 GitHub Actions 中（GITHUB_ACTIONS=true）开启
+		ActionsAnnotations: getEnvBool("ACTIONS_ANNOTATIONS", os.Getenv("GITHUB_ACTIONS") == "true"),
+		// error.log 的大小上限，默认 1MB，0 表示不限制
+		ErrorLogMaxSize: getEnvInt64("ERROR_LOG_MAX_SIZE_KB", 1024) << 10,
+		// 超过上限时的处理：archive 归档为 error-<年月>.log，truncate 只保留最新的部分
+		ErrorLogRotate: getEnvDefault("ERROR_LOG_ROTATE", "archive"),
+		// truncate 时保留的大小，默认 256KB
+		ErrorLogKeepSize: getEnvInt64("ERROR_LOG_KEEP_SIZE_KB", 256) << 10,
+		// 请求使用的 User-Agent，建议带上联系方式，例如：MyBlogroll/1.0 (+https://example.com/about)
+		UserAgent: getEnvDefault("USER_AGENT", defaultUserAgent),
+		// 抓取前检查各主机的 robots.txt，不允许时跳过
+		RespectRobots: getEnvBool("RESPECT_ROBOTS_TXT", false),
+		// 抓取网页使用的代理，例如：http://127.0.0.1:7890、socks5://127.0.0.1:1080，未设置时使用 HTTP_PROXY、HTTPS_PROXY
+		FetchProxy: os.Getenv("FETCH_PROXY"),
+		// 单个响应解压后的大小上限，默认 5MB，0 表示不限制
+		MaxResponseSize: getEnvInt64("MAX_RESPONSE_SIZE_KB", 5<<10) << 10,
+
+		// 上报错误到 Sentry 项目，格式 https://<key>@<host>/<project>
+		SentryDSN:         os.Getenv("SENTRY_DSN"),
+		SentryEnvironment: getEnvDefault("SENTRY_ENVIRONMENT", "production"),
+		// 未使用 Sentry 时，以 JSON POST 上报错误的通用地址
+		ErrorReportURL: os.Getenv("ERROR_REPORT_URL"),
+		// 上报的最低级别，off 表示不上报
+		ErrorReportLevel: getEnvDefault("ERROR_REPORT_LEVEL", "error"),
+	}
+}
+
+// 读取环境变量，未设置时使用默认值
+func getEnvDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// 读取时间间隔类型的环境变量，例如：30m、6h
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("invalid %s %q, using %v\n", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// 读取布尔类型的环境变量，例如：true、1
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Printf("invalid %s %q, using %t\n", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+// 读取整数类型的环境变量
+func getEnvInt64(key string, fallback int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		fmt.Printf("invalid %s %q, using %d\n", key, value, fallback)
+		return fallback
+	}
+	return n
+}
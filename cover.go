@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html"
+)
+
+// 转存的封面图片大小上限
+const coverMaxSize = 2 << 20
+
+var covers = &fileIndex{name: "covers/index.json"}
+
+func init() {
+	registerEnricher(enricher{name: "cover", run: enrichCover, finish: covers.save})
+}
+
+// 从 RSS 条目中提取封面：依次使用条目的图片（media:thumbnail 等）、图片类型的附件、正文和摘要中的第一张图片
+func coverFromItem(item *gofeed.Item) string {
+	var candidates []string
+	if item.Image != nil {
+		candidates = append(candidates, item.Image.URL)
+	}
+	for _, enclosure := range item.Enclosures {
+		if isImageEnclosure(enclosure) {
+			candidates = append(candidates, enclosure.URL)
+		}
+	}
+	candidates = append(candidates, firstImageSrc(item.Content), firstImageSrc(item.Description))
+
+	for _, cover := range candidates {
+		cover = strings.TrimSpace(cover)
+		// 跳过内嵌的 data: 图片
+		if cover != "" && !strings.HasPrefix(cover, "data:") {
+			return resolveURL(item.Link, cover)
+		}
+	}
+	return ""
+}
+
+// 附件是否为图片，没有声明类型时根据扩展名判断
+func isImageEnclosure(enclosure *gofeed.Enclosure) bool {
+	if enclosure.URL == "" {
+		return false
+	}
+	if enclosure.Type != "" {
+		return strings.HasPrefix(enclosure.Type, "image/")
+	}
+	u, err := url.Parse(enclosure.URL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".avif":
+		return true
+	}
+	return false
+}
+
+// HTML 片段中第一张图片的地址，跳过内嵌的 data: 图片
+func firstImageSrc(content string) string {
+	if !strings.Contains(content, "<img") {
+		return ""
+	}
+
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return ""
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		if string(name) != "img" {
+			continue
+		}
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			if string(key) != "src" {
+				continue
+			}
+			if src := strings.TrimSpace(string(val)); src != "" && !strings.HasPrefix(src, "data:") {
+				return src
+			}
+		}
+	}
+}
+
+// 将相对地址解析为绝对地址
+func resolveURL(base string, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return refURL.String()
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// 补充文章封面：RSS 中没有图片时，开启 COVER_FROM_PAGE 后从文章页面的 og:image 中获取；
+// 开启 COVER_REHOST 后将封面转存到数据目录 covers/，避免混合内容和防盗链问题
+func enrichCover(ctx context.Context, config Config, store Storage, article *Article) (bool, error) {
+	changed := false
+
+	if article.Cover == "" && config.CoverFromPage {
+		cover, err := pageCoverURL(ctx, config, article.Link)
+		if err != nil {
+			return false, err
+		}
+		if cover != "" {
+			article.Cover = cover
+			changed = true
+		}
+	}
+
+	if article.Cover == "" || !config.CoverRehost || strings.HasPrefix(article.Cover, dataFileURL(config, "covers/")) {
+		return changed, nil
+	}
+
+	source := article.Cover
+	entry, err := covers.get(store, source)
+	if err != nil {
+		return changed, err
+	}
+	if entry != nil && !allowedImageFile(entry.File) {
+		entry = nil
+	}
+	if entry == nil {
+		data, ext, err := downloadImage(ctx, source, coverMaxSize)
+		if err != nil {
+			return changed, err
+		}
+		sum := sha256.Sum256(data)
+		entry = &fileEntry{
+			Source:  source,
+			File:    "covers/" + archiveID(source) + ext,
+			Hash:    hex.EncodeToString(sum[:]),
+			Updated: time.Now(),
+		}
+		if err := store.WriteFile(entry.File, data); err != nil {
+			return changed, err
+		}
+		covers.set(source, *entry)
+	}
+
+	article.Cover = dataFileURL(config, entry.File)
+	return true, nil
+}
+
+// 文章页面的 og:image（没有时使用 twitter:image），结果缓存在本地缓存的 covers 命名空间中
+func pageCoverURL(ctx context.Context, config Config, link string) (string, error) {
+	cache := openCache(config)
+	if cached, ok := cache.Get("covers", link); ok {
+		return string(cached.Body), nil
+	}
+
+	if err := checkRobots(ctx, link); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var cover string
+	if resp.StatusCode == http.StatusOK {
+		cover = resolveURL(link, metaImage(io.LimitReader(resp.Body, 1<<20)))
+	}
+
+	// 没有封面也缓存，避免每次运行都重新请求
+	if err := cache.Put("covers", cacheEntry{Key: link, Body: []byte(cover)}); err != nil {
+		debugf("Error caching cover for %s: %v", link, err)
+	}
+	return cover, nil
+}
+
+// 读取页面 <head> 中的 og:image 或 twitter:image
+func metaImage(r io.Reader) string {
+	var twitter string
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return twitter
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		switch string(name) {
+		case "body":
+			return twitter
+		case "meta":
+		default:
+			continue
+		}
+
+		var property, content string
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			switch string(key) {
+			case "property", "name":
+				property = strings.ToLower(string(val))
+			case "content":
+				content = strings.TrimSpace(string(val))
+			}
+		}
+
+		switch {
+		case content == "":
+		case property == "og:image" || property == "og:image:url":
+			return content
+		case property == "twitter:image" && twitter == "":
+			twitter = content
+		}
+	}
+}
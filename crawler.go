@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// 所有请求默认使用的 User-Agent，由 USER_AGENT 设置
+	userAgent = defaultUserAgent
+	// 是否遵守 robots.txt，由 RESPECT_ROBOTS_TXT 开启
+	respectRobots bool
+)
+
+const defaultUserAgent = "Grab-latest-RSS/1.0 (+https://github.com/achuanya/Grab-latest-RSS)"
+
+// robots.txt 在内存中缓存的时间，常驻模式下每天重新读取一次
+const robotsTTL = 24 * time.Hour
+
+// httpClient 的传输层：为没有设置 User-Agent 的请求加上 userAgent，避免使用 Go 的默认值被 WAF 拦截；
+// 声明支持 gzip、deflate、br 并解压响应，响应超过 MAX_RESPONSE_SIZE_KB 时读取出错
+type crawlerTransport struct {
+	base http.RoundTripper
+}
+
+func (t *crawlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	// 自行设置 Accept-Encoding 后，Go 不再自动解压 gzip，统一由 decodeResponse 处理
+	decode := req.Header.Get("Accept-Encoding") == ""
+	if decode {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if decode {
+		if err := decodeResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	limitResponse(resp)
+	return resp, nil
+}
+
+func init() {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyForRequest
+	httpClient.Transport = &crawlerTransport{base: transport}
+}
+
+// 按配置设置抓取网页的 User-Agent、代理、响应大小上限和 robots.txt 规则
+func configureCrawler(config Config) error {
+	if config.UserAgent != "" {
+		userAgent = config.UserAgent
+	}
+	respectRobots = config.RespectRobots
+	maxResponseSize = config.MaxResponseSize
+
+	fetchProxy = nil
+	if config.FetchProxy != "" {
+		proxy, err := parseProxyURL(config.FetchProxy)
+		if err != nil {
+			return fmt.Errorf("FETCH_PROXY: %v", err)
+		}
+		fetchProxy = proxy
+	}
+	return nil
+}
+
+// robots.txt 禁止抓取的地址
+type robotsError struct {
+	url string
+}
+
+func (e *robotsError) Error() string {
+	return "disallowed by robots.txt: " + e.url
+}
+
+// 一个主机的 robots.txt 中适用于本程序的规则
+type robotsRules struct {
+	fetched time.Time
+	allow   []string
+	deny    []string
+}
+
+var (
+	robotsMu    sync.Mutex
+	robotsCache = map[string]*robotsRules{}
+)
+
+// 开启 RESPECT_ROBOTS_TXT 时检查 robots.txt 是否允许抓取该地址，不允许时返回 *robotsError
+func checkRobots(ctx context.Context, link string) error {
+	if !respectRobots {
+		return nil
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	rules := hostRobots(ctx, u.Scheme+"://"+u.Host)
+	if !rules.allows(u.RequestURI()) {
+		return &robotsError{url: link}
+	}
+	return nil
+}
+
+// 读取并缓存主机的 robots.txt。robots.txt 不存在或无法读取时允许抓取所有地址
+func hostRobots(ctx context.Context, origin string) *robotsRules {
+	robotsMu.Lock()
+	rules, ok := robotsCache[origin]
+	robotsMu.Unlock()
+	if ok && time.Since(rules.fetched) < robotsTTL {
+		return rules
+	}
+
+	rules = &robotsRules{fetched: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err == nil {
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			debugf("error fetching %s/robots.txt: %v", origin, err)
+		} else {
+			if resp.StatusCode == http.StatusOK {
+				// 与主流搜索引擎一致，只读取前 500KB
+				rules = parseRobots(io.LimitReader(resp.Body, 500<<10), userAgent)
+			}
+			resp.Body.Close()
+		}
+	}
+
+	robotsMu.Lock()
+	robotsCache[origin] = rules
+	robotsMu.Unlock()
+	return rules
+}
+
+// 解析 robots.txt，取 User-agent 与 agent 的产品名匹配的规则组，没有时使用 *
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	product := strings.ToLower(agent)
+	if i := strings.IndexAny(product, "/ "); i >= 0 {
+		product = product[:i]
+	}
+
+	var specific, wildcard robotsRules
+	var foundSpecific bool
+	// 当前规则组的 User-agent 是否匹配，连续的 User-agent 行属于同一组
+	var matchSpecific, matchWildcard, inAgents bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				matchSpecific, matchWildcard = false, false
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			if name == "*" {
+				matchWildcard = true
+			} else if name != "" && strings.Contains(product, name) {
+				matchSpecific, foundSpecific = true, true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			// 空的 Disallow 表示允许所有地址
+			if value == "" {
+				continue
+			}
+			for _, target := range []struct {
+				match bool
+				rules *robotsRules
+			}{{matchSpecific, &specific}, {matchWildcard, &wildcard}} {
+				if !target.match {
+					continue
+				}
+				if key == "allow" {
+					target.rules.allow = append(target.rules.allow, value)
+				} else {
+					target.rules.deny = append(target.rules.deny, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	rules := wildcard
+	if foundSpecific {
+		rules = specific
+	}
+	rules.fetched = time.Now()
+	return &rules
+}
+
+// 最长匹配的规则生效，长度相同时 Allow 优先
+func (r *robotsRules) allows(path string) bool {
+	longestAllow, longestDeny := -1, -1
+	for _, pattern := range r.allow {
+		if robotsMatch(pattern, path) && len(pattern) > longestAllow {
+			longestAllow = len(pattern)
+		}
+	}
+	for _, pattern := range r.deny {
+		if robotsMatch(pattern, path) && len(pattern) > longestDeny {
+			longestDeny = len(pattern)
+		}
+	}
+	return longestDeny < 0 || longestAllow >= longestDeny
+}
+
+// 匹配 robots.txt 的路径规则，支持 * 通配符和表示结尾的 $
+func robotsMatch(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	// 以 $ 结尾时最后一段必须位于末尾
+	last := parts[len(parts)-1]
+	return rest == "" || (len(parts) > 1 && strings.HasSuffix(path, last))
+}
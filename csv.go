@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// rss_data.csv 的表头
+var csvHeader = []string{"name", "title", "link", "date", "dateIso", "domainName", "category"}
+
+// 写入 rss_data.csv，供习惯用表格查看的朋友使用。
+// CSV_EXCEL 开启时加上 UTF-8 BOM 并使用 CRLF 换行，Excel、WPS 双击打开时中文不会乱码
+func writeArticlesCSV(config Config, store Storage, articles []Article) error {
+	var buf bytes.Buffer
+	if config.CSVExcel {
+		buf.WriteString("\ufeff")
+	}
+
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = config.CSVExcel
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, article := range articles {
+		record := []string{article.Name, article.Title, article.Link, article.Date, article.DateISO, article.DomainName, article.Category}
+		if config.CSVExcel {
+			for i := range record {
+				record[i] = escapeSpreadsheetFormula(record[i])
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return store.WriteFile("rss_data.csv", buf.Bytes())
+}
+
+// 以 = + - @ 开头的单元格会被表格软件当作公式执行，前面加上 ' 作为文本显示
+func escapeSpreadsheetFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
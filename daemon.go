@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// 常驻进程，按分组（tier）的调度表分别抓取 RSS，合并后发布
+type daemon struct {
+	config Config
+	store  Storage
+
+	// 分组名称 -> cron 表达式
+	schedules map[string]string
+	// HTTP 服务，未启用时为 nil
+	server *apiServer
+
+	mu sync.Mutex
+	// RSS 地址 -> 最新文章
+	latest map[string]Article
+}
+
+// 解析分组调度表，格式：friends=*/30 * * * *;acquaintances=0 */6 * * *;archives=@daily
+func parseTierSchedules(tiers string, defaultSchedule string) (map[string]string, error) {
+	schedules := map[string]string{defaultTier: defaultSchedule}
+
+	for _, entry := range strings.Split(tiers, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tier %q, expected name=<cron expression>", entry)
+		}
+		schedules[strings.TrimSpace(name)] = strings.TrimSpace(spec)
+	}
+
+	for name, spec := range schedules {
+		if _, err := cron.ParseStandard(spec); err != nil {
+			return nil, fmt.Errorf("invalid schedule for tier %s: %v", name, err)
+		}
+	}
+
+	return schedules, nil
+}
+
+// 启动常驻进程，设置 --serve 时同时提供 HTTP 服务，收到 SIGINT/SIGTERM 后退出
+func runDaemon(config Config, store Storage) error {
+	schedules, err := parseTierSchedules(config.Tiers, config.DaemonSchedule)
+	if err != nil {
+		return err
+	}
+
+	d := &daemon{
+		config:    config,
+		store:     store,
+		schedules: schedules,
+		latest:    map[string]Article{},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if config.ServeAddr != "" {
+		d.server = newAPIServer(config)
+		d.server.start(ctx)
+	}
+
+	// 未启用 --serve 时，可以单独暴露 /metrics
+	if config.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", handleMetrics)
+		fmt.Printf("Serving metrics at http://%s/metrics\n", config.MetricsAddr)
+		startHTTPServer(ctx, config.MetricsAddr, mux)
+	}
+
+	// 先用上一次发布的数据提供服务，首次抓取完成之前不返回 503
+	d.seed()
+
+	// 启动时先完整抓取一次，保证发布的数据包含所有分组
+	d.run("")
+
+	c := cron.New(cron.WithLocation(timeZone), cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	for tier, spec := range schedules {
+		tier := tier
+		if _, err := c.AddFunc(spec, func() { d.run(tier) }); err != nil {
+			return fmt.Errorf("error scheduling tier %s: %v", tier, err)
+		}
+		fmt.Printf("Scheduled tier %s: %s\n", tier, spec)
+	}
+	c.Start()
+
+	<-ctx.Done()
+
+	// 等待正在进行的抓取结束
+	<-c.Stop().Done()
+	return nil
+}
+
+// 抓取指定分组的 RSS 并发布合并后的数据，tier 为空时抓取全部
+func (d *daemon) run(tier string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer reportPanic()
+
+	checkStorageBudget(d.store)
+	defer reportStorageBudget(d.store)
+	// 合并本次运行的写入，在日志写入之后一次提交
+	beginStorageBatch(d.store)
+	defer func() {
+		if err := commitStorageBatch(d.store); err != nil {
+			fmt.Printf("%v\n", err)
+			notifyFatal(d.config, d.store, fmt.Sprintf("Error saving data: %v", err))
+			return
+		}
+		verifyStorageCopies(d.config, d.store)
+		reportStorageTargets(d.config, d.store)
+	}()
+	defer flushLogs()
+
+	lines, err := d.store.ReadFeeds()
+	if err != nil {
+		logError(d.store, "Read RSS feeds error", "err", err)
+		notifyFatal(d.config, d.store, fmt.Sprintf("Error reading RSS feeds: %v", err))
+		return
+	}
+	reportFeedListErrors(d.store, lines)
+	setFeedProxies(parseFeedList(lines))
+	setRenderedFeeds(parseFeedList(lines))
+
+	health, err := loadFeedHealth(d.store)
+	if err != nil {
+		logError(d.store, "Read feed health error", "err", err)
+		health = map[string]*feedHealth{}
+	}
+
+	lines, err = upgradeFeedsToHTTPS(d.config, d.store, lines, health)
+	if err != nil {
+		logError(d.store, "HTTPS upgrade error", "err", err)
+	}
+
+	specs := parseFeedList(lines)
+	setParseOverrides(specs)
+	active := map[string]bool{}
+	var urls []string
+	for _, spec := range specs {
+		// 退役的 RSS 不再抓取，上一次的文章也从当前数据中移除
+		if feedRetired(spec.Options) {
+			continue
+		}
+		active[spec.URL] = true
+		if tier == "" || spec.tier(d.schedules) == tier {
+			urls = append(urls, spec.URL)
+		}
+	}
+
+	runStart := time.Now()
+	// 未抓取的 RSS 保留 d.latest 中上一次的文章
+	articles, results, deferred, err := fetchRSS(d.config, d.store, prioritizeFeeds(specs, health, skipDisabledFeeds(health, urls)))
+	if err != nil {
+		logError(d.store, "Fetch RSS error", "err", err)
+		notifyFatal(d.config, d.store, fmt.Sprintf("Error fetching RSS feeds: %v", err))
+		return
+	}
+
+	// 与 fetch 一样，没有文章和因时间预算未抓取的 RSS 保留上一次发布的文章。
+	// d.latest 中已有的 RSS 不需要补回，避免用较旧的发布数据覆盖
+	var missingDeferred []string
+	for _, feedURL := range deferred {
+		if _, ok := d.latest[feedURL]; !ok {
+			missingDeferred = append(missingDeferred, feedURL)
+		}
+	}
+	var missingResults []feedResult
+	for _, result := range results {
+		if _, ok := d.latest[result.URL]; !ok {
+			missingResults = append(missingResults, result)
+		}
+	}
+	articles = keepPreviousArticles(d.store, health, missingResults, missingDeferred, articles)
+
+	attachFeedOptions(specs, articles)
+	assignCategories(d.config, d.store, articles)
+	articles = rewriteArticleLinks(d.store, specs, articles)
+	articles, results = checkDomainChanges(d.config, d.store, specs, health, articles, results)
+	articles = guardFeedBursts(d.config, d.store, articles, results)
+
+	// 移除已从列表中删除的 RSS
+	for feedURL := range d.latest {
+		if !active[feedURL] {
+			delete(d.latest, feedURL)
+		}
+	}
+	for _, article := range articles {
+		d.latest[article.feedURL] = article
+	}
+
+	merged := make([]Article, 0, len(d.latest))
+	for _, article := range d.latest {
+		merged = append(merged, article)
+	}
+	sortArticles(merged)
+	merged = consolidateMirrors(specs, health, merged)
+	merged = dedupArticles(merged)
+	merged = quarantineUnsafeArticles(d.config, d.store, merged)
+
+	if d.server != nil {
+		if err := d.server.update(merged); err != nil {
+			fmt.Printf("error updating served articles: %v\n", err)
+		}
+	}
+
+	// 第一阶段：立即发布核心数据
+	publishErr := publish(d.config, d.store, merged)
+	if publishErr != nil {
+		logError(d.store, "Save data error", "err", publishErr)
+		notifyFatal(d.config, d.store, fmt.Sprintf("Error saving data: %v", publishErr))
+	}
+
+	// 第二阶段：健康状况、统计和附加输出
+	if err := updateFeedHealth(d.config, d.store, health, results, feedURLs(specs)); err != nil {
+		logError(d.store, "Update feed health error", "err", err)
+	}
+	if err := writeRunStats(d.store, runStart, results); err != nil {
+		logError(d.store, "Write stats error", "err", err)
+	}
+	if err := writeFeedMeta(d.store, results, feedURLs(specs)); err != nil {
+		logError(d.store, "Write feed metadata error", "err", err)
+	}
+	if err := updateChangelog(d.store, specs, results); err != nil {
+		logError(d.store, "Update changelog error", "err", err)
+	}
+	if publishErr != nil {
+		if err := appendRunLog(d.store, tierName(tier), runStart, results, len(merged), 0, publishErr); err != nil {
+			logError(d.store, "Write run log error", "err", err)
+		}
+		return
+	}
+	publishExtras(d.config, d.store, merged)
+
+	if d.config.Badges {
+		if err := writeBadges(d.store, health, skipRetiredFeeds(specs, feedURLs(specs)), merged); err != nil {
+			logError(d.store, "Write badges error", "err", err)
+		}
+	}
+
+	fresh := recordAndNotify(d.config, d.store, merged, results)
+	if err := appendRunLog(d.store, tierName(tier), runStart, results, len(merged), fresh, nil); err != nil {
+		logError(d.store, "Write run log error", "err", err)
+	}
+	if err := appendAudit(d.config, d.store, tierName(tier), lines, len(merged)); err != nil {
+		logError(d.store, "Write audit log error", "err", err)
+	}
+
+	fmt.Printf("[%s] Tier %s: fetched %d feeds, published %d articles\n", localTime().Format("Mon Jan 2 15:04:2006"), tierName(tier), len(urls), len(merged))
+}
+
+// 读取上一次发布的 rss_data.json，按健康记录中的博客主页对应回 RSS 地址填入 d.latest，
+// 重启后本次未抓取或没有文章的 RSS 仍保留原来的文章；启用了 HTTP 服务时立即提供这些数据
+func (d *daemon) seed() {
+	published := loadPublishedArticles(d.store)
+	if len(published) == 0 {
+		return
+	}
+
+	health, err := loadFeedHealth(d.store)
+	if err != nil {
+		logError(d.store, "Read feed health error", "err", err)
+		health = map[string]*feedHealth{}
+	}
+	feeds := map[string]string{}
+	for feedURL, entry := range health {
+		if entry.DomainName != "" {
+			feeds[entry.DomainName] = feedURL
+		}
+	}
+
+	previous := loadPreviousArticles(d.store, feeds)
+	// 与抓取到的文章一样附加 RSS 列表中的选项，id 选项决定文章标识
+	if lines, err := d.store.ReadFeeds(); err == nil {
+		attachFeedOptions(parseFeedList(lines), previous)
+	}
+
+	d.mu.Lock()
+	for _, article := range previous {
+		if _, ok := d.latest[article.feedURL]; !ok {
+			d.latest[article.feedURL] = article
+		}
+	}
+	d.mu.Unlock()
+
+	if d.server != nil {
+		if err := d.server.update(published); err != nil {
+			fmt.Printf("error updating served articles: %v\n", err)
+		}
+	}
+	fmt.Printf("Loaded %d previously published articles\n", len(published))
+}
+
+// 日志中显示的分组名，空表示抓取全部 RSS
+func tierName(tier string) string {
+	if tier == "" {
+		return "all"
+	}
+	return tier
+}
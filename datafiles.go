@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// 图片的 Content-Type -> 文件扩展名。不保存 SVG：SVG 中可以包含脚本，
+// 放到数据目录后与站点同源，会成为存储型 XSS
+var imageExtensions = map[string]string{
+	"image/png":                ".png",
+	"image/jpeg":               ".jpg",
+	"image/gif":                ".gif",
+	"image/webp":               ".webp",
+	"image/avif":               ".avif",
+	"image/x-icon":             ".ico",
+	"image/vnd.microsoft.icon": ".ico",
+}
+
+// 文件是否为允许保存的图片类型，之前保存的 SVG 等文件不再引用，重新获取
+func allowedImageFile(file string) bool {
+	ext := path.Ext(file)
+	for _, allowed := range imageExtensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// 文件索引中的一条记录
+type fileEntry struct {
+	// 原始地址
+	Source string `json:"source"`
+	// 数据目录中的文件，例如 avatars/1a2b3c4d5e6f.png
+	File string `json:"file"`
+	// 文件内容的 SHA-256，内容不变时不重复上传
+	Hash    string    `json:"hash"`
+	Updated time.Time `json:"updated"`
+}
+
+// 已保存到数据目录的文件索引（avatars/index.json、covers/index.json 等），
+// 每次运行时加载一次，所有文章处理完后保存
+type fileIndex struct {
+	// 索引文件名
+	name string
+
+	mu      sync.Mutex
+	loaded  bool
+	changed bool
+	// 博客主页、图片地址或文章链接 -> 保存的文件
+	entries map[string]*fileEntry
+}
+
+// 获取文件记录，首次调用时从数据目录加载索引
+func (idx *fileIndex) get(store Storage, key string) (*fileEntry, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.loaded {
+		idx.entries = map[string]*fileEntry{}
+		data, err := store.ReadFile(idx.name)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &idx.entries); err != nil {
+				return nil, fmt.Errorf("error parsing %s: %v", idx.name, err)
+			}
+		}
+		idx.loaded = true
+	}
+
+	if entry, ok := idx.entries[key]; ok {
+		copied := *entry
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+// 更新文件记录
+func (idx *fileIndex) set(key string, entry fileEntry) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries[key] = &entry
+	idx.changed = true
+}
+
+// 有修改时写回索引，下次运行重新加载
+func (idx *fileIndex) save(store Storage) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	loaded, changed := idx.loaded, idx.changed
+	idx.loaded, idx.changed = false, false
+	if !loaded || !changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(idx.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(idx.name, data)
+}
+
+// 数据目录中文件的公开地址，未设置 FEED_BASE_URL 时为相对数据目录的路径
+func dataFileURL(config Config, file string) string {
+	return config.FeedBaseURL + file
+}
+
+// 下载图片，返回内容和文件扩展名
+func downloadImage(ctx context.Context, source string, maxSize int) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s: %s", source, resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext, ok := imageExtensions[mediaType]
+	if !ok {
+		return nil, "", fmt.Errorf("%s: unsupported content type %q", source, mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxSize {
+		return nil, "", fmt.Errorf("%s: larger than %d bytes", source, maxSize)
+	}
+	return data, ext, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	// 内置时区数据库，Windows 和精简的容器镜像中也能使用 TIMEZONE
+	_ "time/tzdata"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// 文章时间可能使用的格式。没有时区的格式按 TIMEZONE 解析，
+// 月、日、时使用不补零的写法，同时兼容 2024-07-26 和 2024-7-26
+var timeFormats = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+
+	// 不规范的 RFC822：缺少星期、年份为两位或缺少时区
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+
+	// ISO 8601 的变体
+	"2006-1-2T15:04:05Z0700",
+	"2006-1-2T15:04:05",
+	"2006-1-2T15:04",
+	"2006-1-2 15:04:05 -0700",
+	"2006-1-2 15:04:05 MST",
+	"2006-1-2 15:04:05",
+	"2006-1-2 15:04",
+	"2006-1-2",
+
+	// 国内博客常见的写法
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+	"2006.1.2 15:04:05",
+	"2006.1.2",
+	"2006年1月2日 15:04:05",
+	"2006年1月2日 15:04",
+	"2006年1月2日",
+
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"Jan 2, 2006",
+}
+
+// 解析文章时间字段
+func parseTime(timeStr string) (time.Time, error) {
+	timeStr = strings.TrimSpace(timeStr)
+	for _, format := range timeFormats {
+		if t, err := time.ParseInLocation(format, timeStr, timeZone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse time: %s", timeStr)
+}
+
+// 文章的发布时间：依次尝试发布时间和更新时间，优先使用解析器已解析的结果，其次按 timeFormats 解析原始字符串
+func itemTime(item *gofeed.Item) (time.Time, error) {
+	t, err := fieldTime(item.Published, item.PublishedParsed)
+	if err != nil && (item.Updated != "" || item.UpdatedParsed != nil) {
+		t, err = fieldTime(item.Updated, item.UpdatedParsed)
+	}
+	return t, err
+}
+
+// 解析单个时间字段。解析器把没有时区的时间当作 UTC，并把结果统一转换为 UTC，
+// 因此结果为 UTC 时以 parseTime 按 TIMEZONE 解析的结果为准，parseTime 无法解析时再使用解析器的结果
+func fieldTime(raw string, parsed *time.Time) (time.Time, error) {
+	if parsed != nil && parsed.Location() != time.UTC {
+		return *parsed, nil
+	}
+	t, err := parseTime(raw)
+	if err != nil && parsed != nil {
+		return *parsed, nil
+	}
+	return t, err
+}
+
+// 相对时间，例如 3 天前
+const relativeDateFormat = "relative"
+
+var (
+	// date 字段的格式，由 DATE_FORMAT 设置
+	dateFormat = "January 2, 2006"
+	// date 字段的语言，由 DATE_LOCALE 设置
+	dateLocale = "en"
+)
+
+// 各语言的默认格式
+var defaultDateFormats = map[string]string{
+	"en":    "January 2, 2006",
+	"zh-CN": "2006年1月2日",
+}
+
+// 中文的月份和星期，完整名称在前，避免 June 被当作 Jun 替换
+var zhDateNames = strings.NewReplacer(
+	"January", "一月", "February", "二月", "March", "三月", "April", "四月",
+	"May", "五月", "June", "六月", "July", "七月", "August", "八月",
+	"September", "九月", "October", "十月", "November", "十一月", "December", "十二月",
+	"Monday", "星期一", "Tuesday", "星期二", "Wednesday", "星期三", "Thursday", "星期四",
+	"Friday", "星期五", "Saturday", "星期六", "Sunday", "星期日",
+	"Jan", "1月", "Feb", "2月", "Mar", "3月", "Apr", "4月", "Jun", "6月", "Jul", "7月",
+	"Aug", "8月", "Sep", "9月", "Oct", "10月", "Nov", "11月", "Dec", "12月",
+	"Mon", "周一", "Tue", "周二", "Wed", "周三", "Thu", "周四", "Fri", "周五", "Sat", "周六", "Sun", "周日",
+	"AM", "上午", "PM", "下午",
+)
+
+// 按配置设置时区以及 date 字段的格式和语言
+func configureDates(config Config) error {
+	if config.TimeZone != "" {
+		loc, err := time.LoadLocation(config.TimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid TIMEZONE %q: %v", config.TimeZone, err)
+		}
+		timeZone = loc
+	}
+
+	switch locale := strings.ToLower(config.DateLocale); {
+	case strings.HasPrefix(locale, "zh"):
+		dateLocale = "zh-CN"
+	case strings.HasPrefix(locale, "en"):
+		dateLocale = "en"
+	default:
+		return fmt.Errorf("unsupported DATE_LOCALE %q, expected en or zh-CN", config.DateLocale)
+	}
+	dateFormat = defaultDateFormats[dateLocale]
+	if config.DateFormat != "" {
+		dateFormat = config.DateFormat
+	}
+	return nil
+}
+
+// 将文章时间统一格式化，默认例如：July 26, 2024
+func formatTime(t time.Time) string {
+	if dateFormat == relativeDateFormat {
+		return relativeTime(t, time.Now())
+	}
+	return formatTimeLayout(t, dateFormat)
+}
+
+// 按指定格式和 DATE_LOCALE 格式化时间
+func formatTimeLayout(t time.Time, layout string) string {
+	s := t.In(timeZone).Format(layout)
+	if dateLocale == "zh-CN" {
+		s = zhDateNames.Replace(s)
+	}
+	return s
+}
+
+// 存档等不再重新生成的输出中的日期。相对时间写入后会过时，DATE_FORMAT=relative 时
+// 按 dateISO 改用该语言的默认格式
+func archivedDate(date string, dateISO string) string {
+	if dateFormat != relativeDateFormat {
+		return date
+	}
+	t, err := time.Parse(time.RFC3339, dateISO)
+	if err != nil {
+		return date
+	}
+	return formatTimeLayout(t, defaultDateFormats[dateLocale])
+}
+
+// 相对于 now 的时间，例如 3 天前、3 days ago，未来的时间视为刚刚
+func relativeTime(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+	var n int
+	var unit string
+	switch {
+	case d < time.Minute:
+		if dateLocale == "zh-CN" {
+			return "刚刚"
+		}
+		return "just now"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int(d/(365*24*time.Hour)), "year"
+	}
+
+	if dateLocale == "zh-CN" {
+		zhUnits := map[string]string{"minute": "分钟", "hour": "小时", "day": "天", "month": "个月", "year": "年"}
+		return fmt.Sprintf("%d %s前", n, zhUnits[unit])
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// 可以自动发现的 RSS 类型
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+}
+
+// 内容是否为 HTML 页面而不是 RSS
+func looksLikeHTML(body string) bool {
+	head := strings.ToLower(strings.TrimSpace(body))
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return strings.HasPrefix(head, "<!doctype html") || strings.Contains(head, "<html")
+}
+
+// 从 HTML 页面的 <link rel="alternate" type="application/rss+xml"> 中发现 RSS 地址，找不到时返回空字符串
+func discoverFeedURL(body io.Reader, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	z := html.NewTokenizer(body)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return ""
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		switch string(name) {
+		case "body":
+			// <link> 只出现在 <head> 中
+			return ""
+		case "link":
+		default:
+			continue
+		}
+
+		var rel, typ, href string
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			switch string(key) {
+			case "rel":
+				rel = strings.ToLower(string(val))
+			case "type":
+				typ = strings.ToLower(strings.TrimSpace(string(val)))
+			case "href":
+				href = strings.TrimSpace(string(val))
+			}
+		}
+
+		if !strings.Contains(" "+rel+" ", " alternate ") || !feedLinkTypes[typ] || href == "" {
+			continue
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		return base.ResolveReference(ref).String()
+	}
+}
+
+// 获取 RSS 内容，地址指向网站主页时自动发现并获取其中声明的 RSS
+func fetchFeed(cache *diskCache, blocked blocklist, feedURL string, result *feedResult) (*feedBody, error) {
+	if feedRendered(feedURL) {
+		return fetchRenderedFeed(feedURL, result)
+	}
+	body, err := fetchFeedBody(cache, blocked, feedURL, result)
+	if err != nil || !looksLikeHTML(string(body.peek(512))) {
+		return body, err
+	}
+
+	// 没有发现 RSS 时返回剩余的页面内容，由解析器报告格式错误
+	discovered := discoverFeedURL(body, feedURL)
+	if discovered == "" || discovered == feedURL {
+		return body, nil
+	}
+	body.Close()
+	debugf("Discovered feed %s from %s", discovered, feedURL)
+	return fetchFeedBody(cache, blocked, discovered, result)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	cloudflareAPI = "https://api.cloudflare.com/client/v4"
+	dnspodAPI     = "https://dnsapi.cn"
+)
+
+// 发布成功后更新 DNS TXT 记录，内容为生成时间和 rss_data.json 的 CID（发布到 IPFS 时），
+// 外部监控只需查询 DNS 即可确认数据是否新鲜，例如：v=grab1 t=2024-08-01T08:00:00Z cid=bafy...
+func updateDNSBeacon(config Config, store Storage) error {
+	if config.DNSBeaconProvider == "" {
+		return nil
+	}
+	if config.DNSBeaconName == "" || config.DNSBeaconZone == "" || config.DNSBeaconToken == "" {
+		return fmt.Errorf("DNS_BEACON_NAME, DNS_BEACON_ZONE and DNS_BEACON_TOKEN are required")
+	}
+
+	content := "v=grab1 t=" + time.Now().UTC().Format(time.RFC3339)
+	if config.IPFSProvider != "" {
+		manifest, err := loadManifest(store)
+		if err != nil {
+			return err
+		}
+		if cid := manifest.IPFS["rss_data.json"].CID; cid != "" {
+			content += " cid=" + cid
+		}
+	}
+
+	var err error
+	switch config.DNSBeaconProvider {
+	case "cloudflare":
+		err = updateCloudflareTXT(config, content)
+	case "dnspod":
+		err = updateDNSPodTXT(config, content)
+	default:
+		err = fmt.Errorf("unknown DNS_BEACON_PROVIDER %q, expected cloudflare or dnspod", config.DNSBeaconProvider)
+	}
+	if err == nil {
+		debugf("Updated TXT record %s: %s", config.DNSBeaconName, content)
+	}
+	return err
+}
+
+// Cloudflare API 的响应
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+// 通过 Cloudflare API 更新 TXT 记录，不存在时创建。DNS_BEACON_ZONE 为 Zone ID
+func updateCloudflareTXT(config Config, content string) error {
+	records := cloudflareAPI + "/zones/" + url.PathEscape(config.DNSBeaconZone) + "/dns_records"
+
+	var existing []struct {
+		ID string `json:"id"`
+	}
+	if err := cloudflareRequest(config, http.MethodGet, records+"?type=TXT&name="+url.QueryEscape(config.DNSBeaconName), nil, &existing); err != nil {
+		return err
+	}
+
+	record := map[string]interface{}{"type": "TXT", "name": config.DNSBeaconName, "content": `"` + content + `"`, "ttl": 60}
+	if len(existing) > 0 {
+		return cloudflareRequest(config, http.MethodPut, records+"/"+existing[0].ID, record, nil)
+	}
+	return cloudflareRequest(config, http.MethodPost, records, record, nil)
+}
+
+func cloudflareRequest(config Config, method string, endpoint string, payload interface{}, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+config.DNSBeaconToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var parsed cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return fmt.Errorf("error parsing Cloudflare response (%s): %v", resp.Status, err)
+	}
+	if !parsed.Success {
+		var messages []string
+		for _, e := range parsed.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("Cloudflare API error: %s", strings.Join(messages, "; "))
+	}
+	if result != nil {
+		return json.Unmarshal(parsed.Result, result)
+	}
+	return nil
+}
+
+// DNSPod API 的响应状态，code 为 "1" 表示成功
+type dnspodStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// 通过 DNSPod API 更新 TXT 记录，不存在时创建。DNS_BEACON_ZONE 为主域名，DNS_BEACON_TOKEN 为 "ID,Token"
+func updateDNSPodTXT(config Config, content string) error {
+	subDomain := strings.TrimSuffix(strings.TrimSuffix(config.DNSBeaconName, "."), "."+config.DNSBeaconZone)
+	if subDomain == config.DNSBeaconZone {
+		subDomain = "@"
+	}
+	params := url.Values{
+		"domain":      {config.DNSBeaconZone},
+		"sub_domain":  {subDomain},
+		"record_type": {"TXT"},
+	}
+
+	var list struct {
+		Status  dnspodStatus `json:"status"`
+		Records []struct {
+			ID string `json:"id"`
+		} `json:"records"`
+	}
+	if err := dnspodRequest(config, "Record.List", params, &list); err != nil {
+		return err
+	}
+	// 10 表示没有记录
+	if list.Status.Code != "1" && list.Status.Code != "10" {
+		return fmt.Errorf("DNSPod API error: %s", list.Status.Message)
+	}
+
+	params.Set("record_line", "默认")
+	params.Set("value", content)
+	params.Set("ttl", "600")
+	action := "Record.Create"
+	if len(list.Records) > 0 {
+		action = "Record.Modify"
+		params.Set("record_id", list.Records[0].ID)
+	}
+	var result struct {
+		Status dnspodStatus `json:"status"`
+	}
+	if err := dnspodRequest(config, action, params, &result); err != nil {
+		return err
+	}
+	if result.Status.Code != "1" {
+		return fmt.Errorf("DNSPod API error: %s", result.Status.Message)
+	}
+	return nil
+}
+
+func dnspodRequest(config Config, action string, params url.Values, result interface{}) error {
+	form := url.Values{"login_token": {config.DNSBeaconToken}, "format": {"json"}}
+	for key, values := range params {
+		form[key] = values
+	}
+	req, err := http.NewRequest(http.MethodPost, dnspodAPI+"/"+action, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error parsing DNSPod response (%s): %v", resp.Status, err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+)
+
+// 检查博客主页的域名是否与历史记录不同（域名过期、被劫持或停放），
+// 可疑的 RSS 本次视为失败并排除其文章，首次发现时通知人工复核。
+// 确认域名变更后，用 feeds set <url> domain=<新域名> 接受
+func checkDomainChanges(config Config, store Storage, specs []feedSpec, health map[string]*feedHealth, articles []Article, results []feedResult) ([]Article, []feedResult) {
+	accepted := map[string]string{}
+	for _, spec := range specs {
+		if domain := spec.Options["domain"]; domain != "" {
+			accepted[spec.URL] = normalizedHost("https://" + domain)
+		}
+	}
+
+	suspicious := map[string]bool{}
+	for i, result := range results {
+		if result.Err != nil || result.DomainName == "" || result.DomainName == "unknown" {
+			continue
+		}
+		entry := health[result.URL]
+
+		expected := accepted[result.URL]
+		if expected == "" && entry != nil && entry.DomainName != "" {
+			expected = normalizedHost(entry.DomainName)
+		}
+		current := normalizedHost(result.DomainName)
+		if expected == "" || current == expected {
+			continue
+		}
+
+		suspicious[result.URL] = true
+		err := fmt.Errorf("domain changed from %s to %s", expected, current)
+		results[i] = result.failed(errKindDomainChanged, err)
+
+		// 已经通知过的不再重复通知
+		if entry != nil && entry.LastErrorKind == errKindDomainChanged {
+			continue
+		}
+		message := fmt.Sprintf("%s: %v, articles excluded until reviewed. If the move is legitimate, run: feeds set %s domain=%s", result.URL, err, result.URL, current)
+		logWarn(store, "Domain changed", "detail", message)
+		sendAlert(config, store, "domain", message)
+	}
+
+	if len(suspicious) == 0 {
+		return articles, results
+	}
+	kept := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		if !suspicious[article.feedURL] {
+			kept = append(kept, article)
+		}
+	}
+	return kept, results
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 读取上一次发布的 rss_data.json，文件不存在或无法解析时返回 nil
+func loadPublishedArticles(store Storage) []Article {
+	data, err := store.ReadFile("rss_data.json")
+	if err != nil || data == nil {
+		return nil
+	}
+	var articles []Article
+	if err := json.Unmarshal(data, &articles); err != nil {
+		return nil
+	}
+	return articles
+}
+
+// 解析成功但没有任何文章的 RSS，以及因 FETCH_TIMEOUT 未抓取的 RSS 保留上一次发布的文章，按博客主页匹配
+func keepPreviousArticles(store Storage, health map[string]*feedHealth, results []feedResult, deferred []string, articles []Article) []Article {
+	keep := map[string]string{}
+	for _, result := range results {
+		if result.Empty && result.DomainName != "" {
+			keep[result.DomainName] = result.URL
+		}
+	}
+	// 未抓取的 RSS 使用健康记录中的博客主页
+	for _, feedURL := range deferred {
+		if entry := health[feedURL]; entry != nil && entry.DomainName != "" {
+			keep[entry.DomainName] = feedURL
+		}
+	}
+	if len(keep) == 0 {
+		return articles
+	}
+
+	articles = append(articles, loadPreviousArticles(store, keep)...)
+	sortArticles(articles)
+	return articles
+}
+
+// 从上一次发布的 rss_data.json 中取出 feeds（博客主页到 RSS 地址）中每个博客的第一篇文章，
+// 恢复 RSS 地址和发布时间。rss_data.json 不包含 GUID，按链接从 history.json 找回 GUID 和文章标识，
+// 使这些文章不会被当作新文章再次通知
+func loadPreviousArticles(store Storage, feeds map[string]string) []Article {
+	published := loadPublishedArticles(store)
+	if len(published) == 0 {
+		return nil
+	}
+
+	history, err := loadHistory(store)
+	if err != nil {
+		logError(store, "Read history error", "err", err)
+	}
+	// 历史记录中最近发现的在前
+	entries := map[string]historyEntry{}
+	for _, entry := range history {
+		if _, ok := entries[entry.Link]; !ok {
+			entries[entry.Link] = entry
+		}
+	}
+
+	var articles []Article
+	seen := map[string]bool{}
+	for _, article := range published {
+		feedURL, ok := feeds[article.DomainName]
+		if !ok || seen[article.DomainName] {
+			continue
+		}
+		seen[article.DomainName] = true
+
+		article.feedURL = feedURL
+		article.published, _ = time.Parse(time.RFC3339, article.DateISO)
+		if entry, ok := entries[article.Link]; ok {
+			article.guid = entry.GUID
+			article.key = entry.ID
+		}
+		articles = append(articles, article)
+	}
+	return articles
+}
+
+// 本次没有任何文章而上一次发布的数据不为空时拒绝发布，避免整个友链页被清空。
+// 设置 ALLOW_EMPTY_PUBLISH 或 -allow-empty 时仍然发布
+func checkEmptyPublish(config Config, store Storage, articles []Article) error {
+	if len(articles) > 0 || config.AllowEmptyPublish {
+		return nil
+	}
+	if previous := loadPublishedArticles(store); len(previous) > 0 {
+		return fmt.Errorf("no articles to publish, keeping the previous %d articles (set ALLOW_EMPTY_PUBLISH=true or -allow-empty to publish an empty dataset)", len(previous))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 文章补充信息的处理步骤（全文、头像、截图等），返回是否修改了文章
+type enricher struct {
+	name string
+	run  func(ctx context.Context, config Config, store Storage, article *Article) (bool, error)
+	// 所有文章处理完后调用，用于保存该步骤的缓存索引，可以为 nil
+	finish func(store Storage) error
+}
+
+// 已注册的处理步骤，按注册顺序执行
+var enrichers []enricher
+
+// 注册处理步骤，由各步骤在 init 中注册
+func registerEnricher(e enricher) {
+	enrichers = append(enrichers, e)
+}
+
+// 在核心数据发布之后补充文章信息，使用独立的并发数 ENRICH_CONCURRENCY 和时间预算 ENRICH_TIMEOUT，
+// 超时后未处理的文章保持原样。返回是否有文章被修改
+func enrichArticles(config Config, store Storage, articles []Article) bool {
+	if len(enrichers) == 0 || len(articles) == 0 {
+		return false
+	}
+
+	ctx := context.Background()
+	if config.EnrichTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.EnrichTimeout)
+		defer cancel()
+	}
+
+	concurrency := config.EnrichConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+	changed, skipped := false, 0
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range articles {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			skipped += len(articles) - i
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(article *Article) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			// 最先执行，上报完成前 wg.Wait 不会返回
+			defer reportPanic()
+
+			for _, e := range enrichers {
+				if ctx.Err() != nil {
+					return
+				}
+				modified, err := e.run(ctx, config, store, article)
+				if err != nil {
+					debugf("Enrichment %s failed for %s: %v", e.name, article.Link, err)
+					continue
+				}
+				if modified {
+					mu.Lock()
+					changed = true
+					mu.Unlock()
+				}
+			}
+		}(&articles[i])
+	}
+	wg.Wait()
+
+	for _, e := range enrichers {
+		if e.finish == nil {
+			continue
+		}
+		if err := e.finish(store); err != nil {
+			logError(store, "Enrichment error", "enricher", e.name, "err", err)
+		}
+	}
+
+	if skipped > 0 || ctx.Err() != nil {
+		logWarn(store, "Enrichment timeout", "timeout", config.EnrichTimeout, "skipped", skipped)
+	}
+	return changed
+}
+
+// 补充文章信息，有修改时重新保存文章数据
+func enrichAndSave(config Config, store Storage, articles []Article) {
+	start := time.Now()
+	if !enrichArticles(config, store, articles) {
+		return
+	}
+	debugf("Enriched articles in %v", time.Since(start).Round(time.Millisecond))
+
+	if err := store.SaveArticles(articles); err != nil {
+		logError(store, "Save enriched data error", "err", err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+)
+
+// 抓取失败的分类，写入 feed_health.json，前端可以据此显示“该博客证书过期”之类的提示
+const (
+	errKindTimeout  = "timeout"
+	errKindNetwork  = "network"
+	errKindTLS      = "tls"
+	errKindHTTP4xx  = "http-4xx"
+	errKindHTTP5xx  = "http-5xx"
+	errKindParse    = "parse"
+	errKindEncoding = "encoding"
+	errKindBlocked  = "blocked"
+	errKindRobots   = "robots"
+	errKindTooLarge = "too-large"
+	// 博客主页的域名与历史记录不同
+	errKindDomainChanged = "domain-changed"
+)
+
+// 服务器返回了错误状态码
+type httpStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return "unexpected status " + e.Status
+}
+
+// 屏蔽列表拒绝的地址
+type blockedError struct {
+	message string
+}
+
+func (e *blockedError) Error() string {
+	return e.message
+}
+
+// RSS 列表的修改以 Pull Request 提交（FEEDS_PULL_REQUEST），合并之前列表不变
+type feedsProposedError struct {
+	// PR 的地址，已有同样修改的分支时为分支名
+	link string
+}
+
+func (e *feedsProposedError) Error() string {
+	return "feed list change proposed in " + e.link + ", not applied until it is merged"
+}
+
+// WriteFeeds 是否只提交了 Pull Request，列表实际没有修改
+func feedsProposed(err error) bool {
+	var proposedErr *feedsProposedError
+	return errors.As(err, &proposedErr)
+}
+
+// 对获取 RSS 时的错误分类
+func classifyFetchError(err error) string {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= 500 {
+			return errKindHTTP5xx
+		}
+		return errKindHTTP4xx
+	}
+
+	var blockedErr *blockedError
+	if errors.As(err, &blockedErr) {
+		return errKindBlocked
+	}
+
+	var robotsErr *robotsError
+	if errors.As(err, &robotsErr) {
+		return errKindRobots
+	}
+
+	var tooLargeErr *responseTooLargeError
+	if errors.As(err, &tooLargeErr) {
+		return errKindTooLarge
+	}
+
+	var (
+		certErr      *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+	)
+	if errors.As(err, &certErr) || errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) || errors.As(err, &recordErr) || strings.Contains(err.Error(), "tls: ") {
+		return errKindTLS
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errKindTimeout
+	}
+	return errKindNetwork
+}
+
+// 对解析 RSS 时的错误分类，字符集问题单独归为 encoding
+func classifyParseError(err error) string {
+	message := strings.ToLower(err.Error())
+	for _, hint := range []string{"charset", "encoding", "utf-8", "utf8"} {
+		if strings.Contains(message, hint) {
+			return errKindEncoding
+		}
+	}
+	return errKindParse
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// 常见的博客程序，按 generator 中出现的关键字识别
+var knownGenerators = []struct {
+	keyword string
+	name    string
+}{
+	{"wordpress", "WordPress"},
+	{"hugo", "Hugo"},
+	{"hexo", "Hexo"},
+	{"jekyll", "Jekyll"},
+	{"ghost", "Ghost"},
+	{"typecho", "Typecho"},
+	{"halo", "Halo"},
+	{"gatsby", "Gatsby"},
+	{"eleventy", "Eleventy"},
+	{"astro", "Astro"},
+	{"zola", "Zola"},
+	{"gridea", "Gridea"},
+	{"vuepress", "VuePress"},
+	{"vitepress", "VitePress"},
+	{"blogger", "Blogger"},
+	{"medium", "Medium"},
+	{"substack", "Substack"},
+	{"z-blog", "Z-Blog"},
+	{"emlog", "emlog"},
+}
+
+// RSS 自身的元数据
+type feedMeta struct {
+	// RSS 地址
+	URL string `json:"url"`
+	// 博客名称和主页
+	Name       string `json:"name"`
+	DomainName string `json:"domainName"`
+	// RSS 的 <generator> 原文
+	Generator string `json:"generator,omitempty"`
+	// 识别出的博客程序，例如 Hugo、WordPress，无法识别时为 generator 的第一个词
+	Platform string `json:"platform,omitempty"`
+	// RSS 的 <lastBuildDate>（Atom 为 <updated>），RFC3339
+	LastBuildDate string `json:"lastBuildDate,omitempty"`
+	// RSS 的 <language>
+	Language string `json:"language,omitempty"`
+	// 最近一次成功解析的时间
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// 所有 RSS 的元数据，写入 feeds.json
+type feedMetaFile struct {
+	Updated time.Time `json:"updated"`
+	// 博客程序 -> 使用的 RSS 数量
+	Platforms map[string]int `json:"platforms"`
+	// 语言 -> 使用的 RSS 数量
+	Languages map[string]int `json:"languages"`
+	Feeds     []feedMeta     `json:"feeds"`
+}
+
+// 从解析后的 RSS 中提取元数据
+func newFeedMeta(feed *gofeed.Feed) *feedMeta {
+	meta := &feedMeta{
+		Generator: strings.TrimSpace(feed.Generator),
+		Language:  normalizeLanguage(feed.Language),
+		CheckedAt: time.Now(),
+	}
+	meta.Platform = generatorPlatform(meta.Generator)
+	if feed.UpdatedParsed != nil {
+		meta.LastBuildDate = feed.UpdatedParsed.Format(time.RFC3339)
+	}
+	return meta
+}
+
+// 根据 generator 识别博客程序，例如 "Hugo -- gohugo.io" -> Hugo，"https://wordpress.org/?v=6.5" -> WordPress
+func generatorPlatform(generator string) string {
+	lower := strings.ToLower(generator)
+	for _, g := range knownGenerators {
+		if strings.Contains(lower, g.keyword) {
+			return g.name
+		}
+	}
+	if fields := strings.Fields(generator); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// 读取 feeds.json，不存在时返回空记录
+func loadFeedMeta(store Storage) (map[string]feedMeta, error) {
+	data, err := store.ReadFile("feeds.json")
+	if err != nil {
+		return nil, err
+	}
+
+	metas := map[string]feedMeta{}
+	if len(data) == 0 {
+		return metas, nil
+	}
+
+	var file feedMetaFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing feeds.json: %v", err)
+	}
+	for _, meta := range file.Feeds {
+		metas[meta.URL] = meta
+	}
+	return metas, nil
+}
+
+// 用本次成功解析的结果更新 feeds.json，失败的 RSS 保留上一次的记录，已从列表中删除的 RSS 会被移除
+func writeFeedMeta(store Storage, results []feedResult, active []string) error {
+	metas, err := loadFeedMeta(store)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Err != nil || result.Meta == nil {
+			continue
+		}
+		meta := *result.Meta
+		meta.URL = result.URL
+		meta.Name = result.Name
+		meta.DomainName = result.DomainName
+		metas[result.URL] = meta
+	}
+
+	file := feedMetaFile{
+		Updated:   time.Now(),
+		Platforms: map[string]int{},
+		Languages: map[string]int{},
+		Feeds:     make([]feedMeta, 0, len(active)),
+	}
+	for _, feedURL := range active {
+		meta, ok := metas[feedURL]
+		if !ok {
+			continue
+		}
+		if meta.Platform != "" {
+			file.Platforms[meta.Platform]++
+		}
+		if meta.Language != "" {
+			file.Languages[meta.Language]++
+		}
+		file.Feeds = append(file.Feeds, meta)
+	}
+	sort.Slice(file.Feeds, func(i, j int) bool {
+		return file.Feeds[i].URL < file.Feeds[j].URL
+	})
+
+	jsonData, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("feeds.json", jsonData)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/transform"
+)
+
+// RSS 的响应体，边下载边交给解析器，不在内存中保留整个响应。
+// 下载的字节数记入 result，启用缓存时在读取完毕后写入缓存
+type feedBody struct {
+	br   *bufio.Reader
+	src  io.Reader
+	body io.Closer
+
+	result *feedResult
+	// 读取过程中的网络错误，解析失败时据此区分下载失败和格式错误
+	err error
+	eof bool
+
+	// 启用缓存时读到的内容直接写入缓存的临时文件，完整读取后生效
+	saved *cacheWriter
+}
+
+// 包装响应体，cache 为 nil 时不写入缓存
+func newFeedBody(body io.ReadCloser, result *feedResult, cache *diskCache, entry cacheEntry) *feedBody {
+	b := &feedBody{src: body, body: body, result: result}
+	if cache != nil {
+		saved, err := cache.NewWriter("feeds", entry)
+		if err != nil {
+			fmt.Printf("error caching %s: %v\n", entry.Key, err)
+		}
+		b.saved = saved
+	}
+	b.br = bufio.NewReader(readerFunc(b.fill))
+	return b
+}
+
+// 使用缓存中的内容，不计入下载的字节数
+func cachedFeedBody(data []byte) *feedBody {
+	b := &feedBody{src: bytes.NewReader(data), body: io.NopCloser(nil)}
+	b.br = bufio.NewReader(readerFunc(b.fill))
+	return b
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+// 从响应中读取数据，统计字节数并记录错误
+func (b *feedBody) fill(p []byte) (int, error) {
+	n, err := b.src.Read(p)
+	if b.result != nil {
+		b.result.Bytes += int64(n)
+	}
+	if b.saved != nil {
+		b.saved.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.eof = true
+	} else if err != nil && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *feedBody) Read(p []byte) (int, error) {
+	return b.br.Read(p)
+}
+
+// 查看开头的 n 个字节，不影响后续读取
+func (b *feedBody) peek(n int) []byte {
+	head, _ := b.br.Peek(n)
+	return head
+}
+
+// 关闭响应。启用缓存时先读完剩余内容，完整读取后才替换缓存
+func (b *feedBody) Close() error {
+	if b.saved != nil {
+		io.Copy(io.Discard, b.br)
+		if b.eof && b.err == nil {
+			if err := b.saved.commit(); err != nil {
+				fmt.Printf("error caching %s: %v\n", b.saved.path, err)
+			}
+		} else {
+			b.saved.abort()
+		}
+		b.saved = nil
+	}
+	return b.body.Close()
+}
+
+// 清理 XML 中的非法控制字符：C0 控制字符（保留 \t、\n、\r）、DEL 和 C1 控制字符 U+0080-U+009F
+type xmlSanitizer struct {
+	transform.NopResetter
+}
+
+func (xmlSanitizer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		c := src[nSrc]
+		switch {
+		case c < 0x20 && c != '\t' && c != '\n' && c != '\r', c == 0x7F:
+			nSrc++
+			continue
+		case c == 0xC2:
+			// U+0080-U+009F 在 UTF-8 中编码为 C2 80 - C2 9F
+			if nSrc+1 == len(src) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if nSrc+1 < len(src) && src[nSrc+1] >= 0x80 && src[nSrc+1] <= 0x9F {
+				nSrc += 2
+				continue
+			}
+		}
+		if nDst == len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		dst[nDst] = c
+		nDst++
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
+
+// 在读取时清理 XML 中的非法字符
+func sanitizeXML(r io.Reader) io.Reader {
+	return transform.NewReader(r, xmlSanitizer{})
+}
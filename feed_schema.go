@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RSS 列表中选项值的类型
+type feedOptionKind int
+
+const (
+	// 任意非空文本
+	optionText feedOptionKind = iota
+	// 非负整数
+	optionNumber
+	// true、false 或非负整数
+	optionBoolOrNumber
+	// http:// 或 https:// 地址
+	optionURL
+	// 域名
+	optionHost
+	// sed 风格的替换规则
+	optionRewrite
+	// 代理地址或 off
+	optionProxy
+	// 正则表达式
+	optionRegexp
+	// XPath 表达式
+	optionXPath
+	// RSS 的状态：active 或 retired
+	optionState
+	// 文章标识的计算方式，见 articleIDStrategies
+	optionArticleID
+	// true 或 false
+	optionBool
+)
+
+// RSS 列表支持的选项及其类型，新增选项时在这里登记
+var feedOptionSchema = map[string]feedOptionKind{
+	"tier":        optionText,
+	"name":        optionText,
+	"avatar":      optionURL,
+	"category":    optionText,
+	"fulltext":    optionBoolOrNumber,
+	"mirror-of":   optionURL,
+	"rewrite":     optionRewrite,
+	"burst-limit": optionNumber,
+	"domain":      optionHost,
+	"blogroll":    optionURL,
+	"proxy":       optionProxy,
+	"priority":    optionNumber,
+	"state":       optionState,
+	"farewell":    optionText,
+	"id":          optionArticleID,
+	"render":      optionBool,
+
+	"date-from-title": optionRegexp,
+	"link-xpath":      optionXPath,
+}
+
+// RSS 列表中的一处错误，Line 从 1 开始
+type feedListError struct {
+	Line    int
+	URL     string
+	Message string
+}
+
+func (e feedListError) Error() string {
+	return fmt.Sprintf("rss_feeds.txt:%d: %s", e.Line, e.Message)
+}
+
+// 检查 RSS 列表的格式：地址、选项名和选项值，返回所有错误。
+// RSS 列表通常由不熟悉代码的人手工编辑，错误信息指明行号和字段，并尽量给出修改建议
+func validateFeedList(lines []string) []feedListError {
+	var errs []feedListError
+	seen := map[string]int{}
+	urls := map[string]bool{}
+	for _, spec := range parseFeedList(lines) {
+		urls[spec.URL] = true
+	}
+
+	for i, line := range lines {
+		tokens, _ := tokenizeFeedLine(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		feedURL := tokens[0].value
+		report := func(format string, args ...interface{}) {
+			errs = append(errs, feedListError{Line: i + 1, URL: feedURL, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if !isHTTPURL(feedURL) {
+			report("%q is not an http:// or https:// feed URL", feedURL)
+		}
+		if first, ok := seen[feedURL]; ok {
+			report("%s is already listed on line %d", feedURL, first)
+		} else {
+			seen[feedURL] = i + 1
+		}
+
+		for _, token := range tokens[1:] {
+			key, value, ok := strings.Cut(token.value, "=")
+			if !ok {
+				report("%q should be key=value", token.value)
+				continue
+			}
+			if err := validateFeedOption(key, value); err != nil {
+				report("%v", err)
+				continue
+			}
+			if key == "mirror-of" && !urls[value] {
+				report("mirror-of=%s is not in the feed list", value)
+			}
+		}
+	}
+	return errs
+}
+
+// 检查单个选项
+func validateFeedOption(key string, value string) error {
+	kind, ok := feedOptionSchema[key]
+	if !ok {
+		if suggestion := closestFeedOption(key); suggestion != "" {
+			return fmt.Errorf("unknown option %q, did you mean %q?", key, suggestion)
+		}
+		return fmt.Errorf("unknown option %q", key)
+	}
+	if value == "" {
+		return fmt.Errorf("%s must not be empty", key)
+	}
+
+	switch kind {
+	case optionNumber:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("%s must be a number, got %q", key, value)
+		}
+	case optionBoolOrNumber:
+		if n, err := strconv.Atoi(value); err == nil {
+			if n < 0 {
+				return fmt.Errorf("%s must not be negative, got %q", key, value)
+			}
+		} else if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true, false or a number, got %q", key, value)
+		}
+	case optionURL:
+		if !isHTTPURL(value) {
+			return fmt.Errorf("%s must be an http:// or https:// URL, got %q", key, value)
+		}
+	case optionHost:
+		if strings.ContainsAny(value, "/ \t") {
+			return fmt.Errorf("%s must be a domain name such as example.com, got %q", key, value)
+		}
+	case optionRewrite:
+		if _, err := parseRewriteRules(value); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	case optionProxy:
+		if value == "off" {
+			break
+		}
+		if _, err := parseProxyURL(value); err != nil {
+			return fmt.Errorf("%s must be off or an http://, https:// or socks5:// proxy, got %q", key, value)
+		}
+	case optionRegexp:
+		if _, err := compileDateFromTitle(value); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	case optionXPath:
+		if _, err := compileLinkXPath(value); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	case optionArticleID:
+		if _, ok := articleIDStrategies[value]; !ok {
+			return fmt.Errorf("%s must be one of %s, got %q", key, articleIDStrategyNames(), value)
+		}
+	case optionBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", key, value)
+		}
+	case optionState:
+		if value != "active" && value != "retired" {
+			return fmt.Errorf("%s must be active or retired, got %q", key, value)
+		}
+	}
+	return nil
+}
+
+// 是否为 http:// 或 https:// 的绝对地址
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// 与拼错的选项名最接近的已知选项，相差超过 2 个字符时返回空
+func closestFeedOption(key string) string {
+	names := make([]string, 0, len(feedOptionSchema))
+	for name := range feedOptionSchema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(key), name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// 两个字符串的编辑距离
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// 加载 RSS 列表后检查格式，错误记入日志，不影响其余 RSS 的抓取
+func reportFeedListErrors(store Storage, lines []string) {
+	for _, err := range validateFeedList(lines) {
+		logWarn(store, "Feed list error", "line", err.Line, "err", err.Message)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+)
+
+// 未指定分组的 RSS 所属的默认分组
+const defaultTier = "default"
+
+// RSS 列表中的一行，格式：<RSS 地址> [key=value ...]，# 开头的行为注释，
+// 含空格的值用双引号括起来，引号内可以用 \" 和 \\ 转义
+//
+//	https://lhasa.icu/atom.xml tier=friends name="游钓四方的博客" category=骑行
+type feedSpec struct {
+	// RSS 地址
+	URL string
+	// 附加选项，例如 tier=friends
+	Options map[string]string
+}
+
+// 行内的一个字段，value 为去掉引号和转义后的内容，start、end 为原文中的位置
+type lineToken struct {
+	value      string
+	start, end int
+}
+
+// 按空白拆分一行，双引号内的空白不拆分。返回字段和行尾注释的起始位置（没有注释时为行长度）
+func tokenizeFeedLine(line string) ([]lineToken, int) {
+	var tokens []lineToken
+	i, n := 0, len(line)
+	for {
+		for i < n && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= n {
+			return tokens, n
+		}
+		// 注释
+		if line[i] == '#' {
+			return tokens, i
+		}
+
+		start := i
+		var b strings.Builder
+		quoted := false
+		for i < n {
+			c := line[i]
+			if quoted {
+				switch {
+				case c == '\\' && i+1 < n && (line[i+1] == '"' || line[i+1] == '\\'):
+					b.WriteByte(line[i+1])
+					i += 2
+					continue
+				case c == '"':
+					quoted = false
+				default:
+					b.WriteByte(c)
+				}
+				i++
+				continue
+			}
+			if c == ' ' || c == '\t' {
+				break
+			}
+			if c == '"' {
+				quoted = true
+			} else {
+				b.WriteByte(c)
+			}
+			i++
+		}
+		tokens = append(tokens, lineToken{value: b.String(), start: start, end: i})
+	}
+}
+
+// 格式化选项，值含空白、引号或以 # 开头时加上双引号
+func formatLineOption(key string, value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t\"") && !strings.HasPrefix(value, "#") {
+		return key + "=" + value
+	}
+	escaped := strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value)
+	return key + "=\"" + escaped + "\""
+}
+
+// 解析 RSS 列表，跳过空行和注释
+func parseFeedList(lines []string) []feedSpec {
+	var specs []feedSpec
+	for _, line := range lines {
+		tokens, _ := tokenizeFeedLine(line)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		spec := feedSpec{URL: tokens[0].value, Options: map[string]string{}}
+		for _, token := range tokens[1:] {
+			key, value, _ := strings.Cut(token.value, "=")
+			spec.Options[key] = value
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// 提取 RSS 地址列表
+func feedURLs(specs []feedSpec) []string {
+	urls := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		urls = append(urls, spec.URL)
+	}
+	return urls
+}
+
+// 将 RSS 列表中的选项附加到对应的文章上，供补充信息等后续步骤使用，
+// 并用 name、avatar、category 选项覆盖 RSS 自身提供的信息
+func attachFeedOptions(specs []feedSpec, articles []Article) {
+	options := map[string]map[string]string{}
+	for _, spec := range specs {
+		options[spec.URL] = spec.Options
+	}
+	for i := range articles {
+		opts := options[articles[i].feedURL]
+		articles[i].options = opts
+
+		if name := cleanText(opts["name"]); name != "" {
+			articles[i].Name = name
+		}
+		if avatar := opts["avatar"]; avatar != "" {
+			articles[i].Avatar = avatar
+		}
+		if category := opts["category"]; category != "" {
+			articles[i].Category = category
+		}
+	}
+}
+
+// RSS 所属分组，未配置调度的分组归入默认分组
+func (f feedSpec) tier(schedules map[string]string) string {
+	tier := f.Options["tier"]
+	if _, ok := schedules[tier]; !ok {
+		return defaultTier
+	}
+	return tier
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 对 RSS 列表的一处修改。工具只改动涉及的行，其余行（包括注释、空行、未知格式）原样保留
+type feedEdit struct {
+	// add、remove、replace、set
+	Op string
+	// 目标 RSS 地址
+	URL string
+	// replace 的新地址
+	NewURL string
+	// add、set 的选项
+	Options map[string]string
+}
+
+// 查找 RSS 所在的行及其地址在行内的位置
+func findFeedLine(lines []string, feedURL string) (int, int) {
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		fields := strings.Fields(trimmed)
+		if fields[0] == feedURL {
+			return i, len(line) - len(trimmed)
+		}
+	}
+	return -1, -1
+}
+
+// 在行内设置选项，已存在则替换原值，否则追加在行尾注释之前
+func setLineOption(line string, key string, value string) string {
+	option := formatLineOption(key, value)
+
+	tokens, comment := tokenizeFeedLine(line)
+	for _, token := range tokens[1:] {
+		if k, _, _ := strings.Cut(token.value, "="); k == key {
+			return line[:token.start] + option + line[token.end:]
+		}
+	}
+
+	if comment < len(line) {
+		return strings.TrimRight(line[:comment], " \t") + " " + option + " " + line[comment:]
+	}
+	return strings.TrimRight(line, " \t") + " " + option
+}
+
+// 格式化一行 RSS，选项按名称排序输出
+func formatFeedLine(feedURL string, options map[string]string) string {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	line := feedURL
+	for _, key := range keys {
+		line += " " + formatLineOption(key, options[key])
+	}
+	return line
+}
+
+// 按顺序应用修改，返回新的 RSS 列表
+func applyFeedEdits(lines []string, edits []feedEdit) ([]string, error) {
+	result := append([]string(nil), lines...)
+
+	// 明确被修改或删除的 RSS
+	touched := map[string]bool{}
+
+	for _, edit := range edits {
+		i, offset := findFeedLine(result, edit.URL)
+
+		switch edit.Op {
+		case "add":
+			if i >= 0 {
+				return nil, fmt.Errorf("%s is already in the feed list", edit.URL)
+			}
+			result = append(result, formatFeedLine(edit.URL, edit.Options))
+
+		case "remove":
+			if i < 0 {
+				return nil, fmt.Errorf("%s is not in the feed list", edit.URL)
+			}
+			result = append(result[:i], result[i+1:]...)
+			touched[edit.URL] = true
+
+		case "replace":
+			if i < 0 {
+				return nil, fmt.Errorf("%s is not in the feed list", edit.URL)
+			}
+			line := result[i]
+			result[i] = line[:offset] + edit.NewURL + line[offset+len(edit.URL):]
+			touched[edit.URL] = true
+
+		case "set":
+			if i < 0 {
+				return nil, fmt.Errorf("%s is not in the feed list", edit.URL)
+			}
+			keys := make([]string, 0, len(edit.Options))
+			for key := range edit.Options {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				result[i] = setLineOption(result[i], key, edit.Options[key])
+			}
+
+		default:
+			return nil, fmt.Errorf("unknown feed edit %q", edit.Op)
+		}
+	}
+
+	// 安全检查：未被明确修改的 RSS 必须全部保留
+	remaining := map[string]bool{}
+	for _, spec := range parseFeedList(result) {
+		remaining[spec.URL] = true
+	}
+	for _, spec := range parseFeedList(lines) {
+		if !touched[spec.URL] && !remaining[spec.URL] {
+			return nil, fmt.Errorf("refusing to drop untouched feed %s", spec.URL)
+		}
+	}
+
+	return result, nil
+}
+
+// 读取、修改并写回 RSS 列表
+func editFeedList(store Storage, edits []feedEdit) error {
+	lines, err := store.ReadFeeds()
+	if err != nil {
+		return err
+	}
+
+	updated, err := applyFeedEdits(lines, edits)
+	if err != nil {
+		return err
+	}
+
+	// 拒绝引入新的格式错误，列表中原有的错误不影响修改
+	existing := map[string]bool{}
+	for _, e := range validateFeedList(lines) {
+		existing[e.URL+"\n"+e.Message] = true
+	}
+	for _, e := range validateFeedList(updated) {
+		if !existing[e.URL+"\n"+e.Message] {
+			return e
+		}
+	}
+
+	return store.WriteFeeds(updated)
+}
+
+// 处理 feeds 子命令：
+//
+//	feeds add <url> [key=value ...]
+//	feeds remove <url>
+//	feeds replace <url> <new-url>
+//	feeds set <url> key=value [key=value ...]
+//
+// -reason 记录修改原因，与 MAINTAINER 一起写入 feed_changes.json 并出现在 CHANGELOG.md 中
+func runFeedsCommand(store Storage, args []string) error {
+	fs := flag.NewFlagSet("feeds", flag.ExitOnError)
+	reason := fs.String("reason", "", "why the feed list is changed, recorded in CHANGELOG.md")
+	fs.Parse(args)
+	args = fs.Args()
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: feeds [-reason text] add|remove|replace|set <url> [...]")
+	}
+
+	edit := feedEdit{Op: args[0], URL: args[1], Options: map[string]string{}}
+	switch edit.Op {
+	case "replace":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: feeds replace <url> <new-url>")
+		}
+		edit.NewURL = args[2]
+	case "add", "set":
+		for _, arg := range args[2:] {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("invalid option %q, expected key=value", arg)
+			}
+			edit.Options[key] = value
+		}
+	}
+
+	if err := editFeedList(store, []feedEdit{edit}); err != nil {
+		// 只提交了 PR 时修改尚未生效，合并后才记录变更
+		if feedsProposed(err) {
+			fmt.Printf("%v\n", err)
+			return nil
+		}
+		return err
+	}
+	// 没有原因也没有维护者署名时不需要记录
+	if *reason == "" && currentMaintainer == "" {
+		return nil
+	}
+	return recordFeedChanges(store, []feedChange{{Type: "feeds-" + edit.Op, URL: edit.URL, NewURL: edit.NewURL, Reason: *reason}})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+)
+
+// 朋友圈时间线页面
+var friendsPageTemplate = newPageTemplate("friends", `<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+{{template "head"}}
+<title>{{.Title}}</title>
+</head>
+<body>
+<main>
+<header>
+<h1>{{.Title}}</h1>
+<p class="meta">更新于 <time datetime="{{.Updated.Format "2006-01-02T15:04:05Z07:00"}}">{{.Updated.Format "2006-01-02 15:04"}}</time></p>
+</header>
+<ul aria-label="朋友们的最新文章">
+{{range .Articles}}<li{{with .Language}} lang="{{.}}"{{end}}>
+<article>
+<h2><a href="{{.Link}}">{{.Title}}</a></h2>
+<p class="meta">{{with .Avatar}}<img class="avatar" src="{{.}}" alt="" loading="lazy"> {{end}}<a href="{{.DomainName}}" aria-label="博客主页：{{.Name}}">{{.Name}}</a> · <time datetime="{{.DateISO}}">{{.Date}}</time></p>
+{{with .Summary}}<p>{{.}}</p>{{end}}
+</article>
+</li>
+{{end}}</ul>
+</main>
+</body>
+</html>
+`)
+
+// 将所有朋友的最新文章渲染为独立的 friends.html 页面，不依赖 JS 前端即可嵌入
+func renderFriendsPage(config Config, articles []Article) ([]byte, error) {
+	var page bytes.Buffer
+	err := friendsPageTemplate.Execute(&page, map[string]interface{}{
+		"Lang":     config.SiteLanguage,
+		"Title":    config.FriendsFeedTitle,
+		"Updated":  localTime(),
+		"Articles": articles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.Bytes(), nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// 文章页面大小上限
+const fullTextPageMaxSize = 2 << 20
+
+var fullTexts = &fileIndex{name: "content/index.json"}
+
+func init() {
+	registerEnricher(enricher{name: "fulltext", run: enrichFullText, finish: fullTexts.save})
+}
+
+// 提取的全文，写入数据目录 content/<id>.json
+type fullTextContent struct {
+	Link  string `json:"link"`
+	Title string `json:"title"`
+	// 按段落分隔的纯文本，段落之间为空行
+	Text string `json:"text"`
+	// 是否被 fulltext=<字数> 截断
+	Truncated bool      `json:"truncated,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// 解析 RSS 列表中的 fulltext 选项：true 保存全文，数字表示最多保存的字数
+func fullTextLimit(value string) (enabled bool, maxRunes int) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n > 0, n
+	}
+	enabled, _ = strconv.ParseBool(value)
+	return enabled, 0
+}
+
+// 为只输出摘要的 RSS 抓取文章页面，提取正文保存到 content/，并在文章的 content 字段中引用。
+// 只处理在 RSS 列表中设置了 fulltext 选项的 RSS，已提取过的文章不再重复抓取
+func enrichFullText(ctx context.Context, config Config, store Storage, article *Article) (bool, error) {
+	enabled, maxRunes := fullTextLimit(article.options["fulltext"])
+	if !enabled || article.Link == "" {
+		return false, nil
+	}
+
+	entry, err := fullTexts.get(store, article.Link)
+	if err != nil {
+		return false, err
+	}
+	if entry == nil {
+		text, err := fetchFullText(ctx, article.Link)
+		if err != nil {
+			return false, err
+		}
+		if text == "" {
+			return false, fmt.Errorf("no content found in %s", article.Link)
+		}
+
+		content := fullTextContent{Link: article.Link, Title: article.Title, Text: text, FetchedAt: time.Now()}
+		if runes := []rune(text); maxRunes > 0 && len(runes) > maxRunes {
+			content.Text = string(runes[:maxRunes]) + "…"
+			content.Truncated = true
+		}
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return false, err
+		}
+
+		sum := sha256.Sum256(data)
+		entry = &fileEntry{
+			Source:  article.Link,
+			File:    "content/" + archiveID(article.Link) + ".json",
+			Hash:    hex.EncodeToString(sum[:]),
+			Updated: content.FetchedAt,
+		}
+		if err := store.WriteFile(entry.File, data); err != nil {
+			return false, err
+		}
+		fullTexts.set(article.Link, *entry)
+	}
+
+	contentURL := dataFileURL(config, entry.File)
+	if article.Content == contentURL {
+		return false, nil
+	}
+	article.Content = contentURL
+	return true, nil
+}
+
+// 抓取文章页面并提取正文
+func fetchFullText(ctx context.Context, link string) (string, error) {
+	if err := checkRobots(ctx, link); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, fullTextPageMaxSize))
+	if err != nil {
+		return "", err
+	}
+	return extractMainText(doc), nil
+}
+
+// 不属于正文的元素，连同内容一起跳过
+var fullTextSkipped = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "iframe": true, "svg": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "button": true,
+}
+
+// 块级元素，前后分段
+var fullTextBlocks = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "blockquote": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "table": true, "tr": true, "figure": true, "figcaption": true, "hr": true,
+}
+
+// 类似 Readability 的正文提取：优先使用 <article>、<main>，否则选取直接包含段落文字最多的元素
+func extractMainText(doc *html.Node) string {
+	var articles, mains []*html.Node
+	scores := map[*html.Node]int{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if fullTextSkipped[n.Data] {
+				return
+			}
+			switch n.Data {
+			case "article":
+				articles = append(articles, n)
+			case "main":
+				mains = append(mains, n)
+			case "p", "pre", "blockquote":
+				if n.Parent != nil {
+					scores[n.Parent] += len([]rune(strings.TrimSpace(nodeText(n))))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var root *html.Node
+	switch {
+	case len(articles) > 0:
+		// 页面有多个 <article>（例如评论）时，取文字最多的一个
+		best := 0
+		for _, n := range articles {
+			if length := len(nodeText(n)); root == nil || length > best {
+				root, best = n, length
+			}
+		}
+	case len(mains) > 0:
+		root = mains[0]
+	default:
+		best := 0
+		for n, score := range scores {
+			if score > best {
+				root, best = n, score
+			}
+		}
+	}
+	if root == nil {
+		return ""
+	}
+
+	var paragraphs []string
+	var current strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		current.Reset()
+	}
+
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			current.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if fullTextSkipped[n.Data] {
+				return
+			}
+			if n.Data == "br" {
+				current.WriteString(" ")
+				return
+			}
+		}
+
+		block := n.Type == html.ElementNode && fullTextBlocks[n.Data]
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+		if block {
+			flush()
+		}
+	}
+	collect(root)
+	flush()
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// 元素中的全部文字，跳过脚本、样式等
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && fullTextSkipped[n.Data] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 单个 RSS 的本次抓取结果
+type feedResult struct {
+	// RSS 地址
+	URL string
+	// 抓取耗时
+	Duration time.Duration
+	// HTTP 请求次数，包括重定向
+	Requests int
+	// 下载的字节数，内容未变化时为 0
+	Bytes int64
+	// 服务器返回 304，使用了本地缓存
+	NotModified bool
+	// 抓取或解析错误，成功时为 nil
+	Err error
+	// 错误分类，见 errors.go
+	ErrKind string
+	// 博客名称和主页，解析成功时填写
+	Name       string
+	DomainName string
+	// RSS 自身的元数据，解析成功时填写，见 feed_meta.go
+	Meta *feedMeta
+	// 发布时间在 FEED_BURST_WINDOW 内的文章数量，见 burst.go
+	RecentItems int
+	// RSS 解析成功但没有任何文章，发布时保留上一次的文章，见 empty.go
+	Empty bool
+}
+
+// 标记抓取失败
+func (r feedResult) failed(kind string, err error) feedResult {
+	r.Err = err
+	r.ErrKind = kind
+	return r
+}
+
+// 单个 RSS 的历史健康状况
+type feedHealth struct {
+	// RSS 地址
+	URL string `json:"url"`
+	// 博客名称和主页，来自最近一次成功解析的 RSS
+	Name       string `json:"name,omitempty"`
+	DomainName string `json:"domainName,omitempty"`
+	// 最近一次成功的时间，RFC3339
+	LastSuccess string `json:"lastSuccess,omitempty"`
+	// 最近一次失败的时间，RFC3339
+	LastFailure string `json:"lastFailure,omitempty"`
+	// 最近一次失败的原因
+	LastError string `json:"lastError,omitempty"`
+	// 最近一次失败的分类：timeout、network、tls、http-4xx、http-5xx、parse、encoding、blocked、domain-changed
+	LastErrorKind string `json:"lastErrorKind,omitempty"`
+	// 连续失败次数
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// 累计成功次数
+	Successes int `json:"successes"`
+	// 累计失败次数
+	Failures int `json:"failures"`
+	// 成功抓取的平均耗时，毫秒
+	AverageLatencyMs float64 `json:"averageLatencyMs"`
+	// 连续失败过多被自动停用，停用期间只做定期复查
+	Disabled bool `json:"disabled,omitempty"`
+	// 停用时间，RFC3339
+	DisabledSince string `json:"disabledSince,omitempty"`
+	// 下次复查时间，RFC3339
+	NextCheck string `json:"nextCheck,omitempty"`
+	// 最近一次尝试 HTTPS 的时间，RFC3339，仅用于 http:// 的 RSS
+	LastHTTPSProbe string `json:"lastHttpsProbe,omitempty"`
+	// RSS 可以解析但没有任何文章，不计为失败
+	Empty bool `json:"empty,omitempty"`
+	// 开始没有文章的时间，RFC3339
+	EmptySince string `json:"emptySince,omitempty"`
+}
+
+// 读取 feed_health.json，文件不存在时返回空表
+func loadFeedHealth(store Storage) (map[string]*feedHealth, error) {
+	health := map[string]*feedHealth{}
+
+	data, err := store.ReadFile("feed_health.json")
+	if err != nil || data == nil {
+		return health, err
+	}
+
+	var entries []*feedHealth
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing feed_health.json: %v", err)
+	}
+	for _, entry := range entries {
+		health[entry.URL] = entry
+	}
+	return health, nil
+}
+
+// 跳过已停用且未到复查时间的 RSS
+func skipDisabledFeeds(health map[string]*feedHealth, urls []string) []string {
+	now := time.Now()
+	var enabled []string
+	for _, feedURL := range urls {
+		entry := health[feedURL]
+		if entry != nil && entry.Disabled {
+			nextCheck, err := time.Parse(time.RFC3339, entry.NextCheck)
+			if err == nil && now.Before(nextCheck) {
+				continue
+			}
+		}
+		enabled = append(enabled, feedURL)
+	}
+	return enabled
+}
+
+// 根据本次抓取结果更新 feed_health.json，移除已不在 RSS 列表中的记录
+func updateFeedHealth(config Config, store Storage, health map[string]*feedHealth, results []feedResult, active []string) error {
+	now := time.Now().Format(time.RFC3339)
+	nextCheck := time.Now().Add(config.RecheckInterval).Format(time.RFC3339)
+
+	for _, result := range results {
+		entry := health[result.URL]
+		if entry == nil {
+			entry = &feedHealth{URL: result.URL}
+			health[result.URL] = entry
+		}
+
+		if result.Err != nil {
+			entry.LastFailure = now
+			entry.LastError = result.Err.Error()
+			entry.LastErrorKind = result.ErrKind
+			entry.ConsecutiveFailures++
+			entry.Failures++
+
+			// 连续失败达到上限，停用并等待复查
+			if entry.Disabled {
+				entry.NextCheck = nextCheck
+			} else if config.DisableAfterFailures > 0 && entry.ConsecutiveFailures >= config.DisableAfterFailures {
+				entry.Disabled = true
+				entry.DisabledSince = now
+				entry.NextCheck = nextCheck
+				logWarn(store, "Feed disabled", "feed", result.URL, "failures", entry.ConsecutiveFailures, "nextCheck", nextCheck)
+			}
+			continue
+		}
+
+		// 复查成功，恢复抓取
+		if entry.Disabled {
+			entry.Disabled = false
+			entry.DisabledSince = ""
+			entry.NextCheck = ""
+			logWarn(store, "Feed recovered", "feed", result.URL)
+		}
+
+		// 累计平均耗时
+		latency := float64(result.Duration) / float64(time.Millisecond)
+		entry.AverageLatencyMs = (entry.AverageLatencyMs*float64(entry.Successes) + latency) / float64(entry.Successes+1)
+		entry.LastSuccess = now
+		entry.Name = result.Name
+		entry.DomainName = result.DomainName
+		entry.ConsecutiveFailures = 0
+		entry.Successes++
+
+		if result.Empty && !entry.Empty {
+			entry.Empty = true
+			entry.EmptySince = now
+			logWarn(store, "Feed has no items, keeping previous article", "feed", result.URL)
+		} else if !result.Empty {
+			entry.Empty = false
+			entry.EmptySince = ""
+		}
+	}
+
+	activeFeeds := map[string]bool{}
+	for _, feedURL := range active {
+		activeFeeds[feedURL] = true
+	}
+
+	entries := make([]*feedHealth, 0, len(health))
+	for feedURL, entry := range health {
+		if activeFeeds[feedURL] {
+			entries = append(entries, entry)
+		}
+	}
+
+	// 连续失败次数多的在前，便于清理
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].ConsecutiveFailures != entries[j].ConsecutiveFailures {
+			return entries[i].ConsecutiveFailures > entries[j].ConsecutiveFailures
+		}
+		return entries[i].URL < entries[j].URL
+	})
+
+	jsonData, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("feed_health.json", jsonData)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 历史记录中的一篇文章
+type historyEntry struct {
+	// GUID、链接或标题的哈希，见 articleKey
+	ID string `json:"id"`
+	// RSS 中的 GUID，rss_data.json 不包含 GUID，保留上一次的文章时从这里找回
+	GUID    string `json:"guid,omitempty"`
+	Name    string `json:"name"`
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	FeedURL string `json:"feedUrl"`
+	// 文章发布时间，RFC3339
+	DateISO string `json:"dateIso"`
+	// 首次抓取到的时间，RFC3339
+	FirstSeen string `json:"firstSeen"`
+}
+
+// 文章的唯一标识：按 id 选项或 ARTICLE_ID 计算，默认优先使用 RSS 中的 GUID，没有时使用链接
+func articleKey(article Article) string {
+	if article.key != "" {
+		return article.key
+	}
+	key := articleIDStrategies[articleIDStrategy(article)](article)
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// 去除重复的文章（例如同一博客的多个 RSS 地址），保留排在前面的一篇
+func dedupArticles(articles []Article) []Article {
+	seen := map[string]bool{}
+	result := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		key := articleKey(article)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, article)
+	}
+	return result
+}
+
+// 读取 history.json，文件不存在时返回 nil
+func loadHistory(store Storage) ([]historyEntry, error) {
+	data, err := store.ReadFile("history.json")
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var history []historyEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("error parsing history.json: %v", err)
+	}
+	return history, nil
+}
+
+// 将本次抓取的文章并入历史，返回新的历史记录和此前从未见过的文章，由 saveHistory 写回。
+// 首次运行没有历史记录，只建立基线，不视为新文章；超过保留期限的记录会被清理
+func mergeHistory(config Config, store Storage, articles []Article) ([]historyEntry, []Article, error) {
+	history, err := loadHistory(store)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseline := history == nil
+
+	seen := map[string]bool{}
+	for _, entry := range history {
+		seen[entry.ID] = true
+	}
+
+	now := time.Now()
+	var fresh []Article
+	for _, article := range articles {
+		id := articleKey(article)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		history = append(history, historyEntry{
+			ID:        id,
+			GUID:      article.guid,
+			Name:      article.Name,
+			Title:     article.Title,
+			Link:      article.Link,
+			FeedURL:   article.feedURL,
+			DateISO:   article.DateISO,
+			FirstSeen: now.Format(time.RFC3339),
+		})
+		if !baseline {
+			fresh = append(fresh, article)
+		}
+	}
+
+	// 清理超过保留期限的记录
+	if config.HistoryRetention > 0 {
+		cutoff := now.Add(-config.HistoryRetention)
+		kept := history[:0]
+		for _, entry := range history {
+			firstSeen, err := time.Parse(time.RFC3339, entry.FirstSeen)
+			if err == nil && firstSeen.Before(cutoff) {
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		history = kept
+	}
+
+	// 最近发现的在前
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].FirstSeen > history[j].FirstSeen
+	})
+
+	return history, fresh, nil
+}
+
+// 写回 history.json
+func saveHistory(store Storage, history []historyEntry) error {
+	jsonData, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("history.json", jsonData)
+}
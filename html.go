@@ -0,0 +1,28 @@
+package main
+
+import (
+	"html/template"
+)
+
+// 所有生成的 HTML 页面共用的布局：语义化结构、lang 属性、支持深色模式的最小样式
+const htmlLayout = `{{define "head"}}<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="color-scheme" content="light dark">
+<style>
+:root { color-scheme: light dark; --fg: #1f2328; --bg: #ffffff; --muted: #59636e; --link: #0969da; }
+@media (prefers-color-scheme: dark) {
+  :root { --fg: #e6edf3; --bg: #0d1117; --muted: #9198a1; --link: #4493f8; }
+}
+body { max-width: 42rem; margin: 0 auto; padding: 1.5rem 1rem; font: 1rem/1.7 system-ui, -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; color: var(--fg); background: var(--bg); }
+a { color: var(--link); }
+a:focus-visible { outline: 2px solid var(--link); outline-offset: 2px; }
+.meta, time { color: var(--muted); }
+ul { padding-left: 1.25rem; }
+.avatar { width: 1.25em; height: 1.25em; vertical-align: -0.25em; border-radius: 4px; }
+</style>{{end}}`
+
+// 基于公共布局创建页面模板
+func newPageTemplate(name string, page string) *template.Template {
+	t := template.Must(template.New(name).Parse(htmlLayout))
+	return template.Must(t.Parse(page))
+}
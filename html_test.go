@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPageTemplates(t *testing.T) {
+	article := Article{
+		DomainName: "https://example.com",
+		Name:       "示例博客",
+		Title:      "第一篇 <文章>",
+		Link:       "https://example.com/posts/1",
+		Date:       "2024-08-01 08:00",
+		DateISO:    "2024-08-01T08:00:00+08:00",
+		Language:   "en",
+		Summary:    "摘要",
+	}
+
+	tests := []struct {
+		name string
+		run  func(*bytes.Buffer) error
+		want []string
+	}{
+		{
+			name: "archive page",
+			run: func(b *bytes.Buffer) error {
+				return archivePageTemplate.Execute(b, map[string]interface{}{
+					"Lang":     "zh-CN",
+					"Article":  article,
+					"Summary":  article.Summary,
+					"Snapshot": "https://web.archive.org/web/" + article.Link,
+				})
+			},
+			want: []string{
+				`<html lang="zh-CN">`,
+				`<main>`,
+				`<article lang="en">`,
+				`<nav aria-label="文章链接">`,
+				`<time datetime="2024-08-01T08:00:00&#43;08:00">2024-08-01 08:00</time>`,
+				`第一篇 &lt;文章&gt;`,
+			},
+		},
+		{
+			name: "archive index",
+			run: func(b *bytes.Buffer) error {
+				return archiveIndexTemplate.Execute(b, map[string]interface{}{
+					"Lang":    "zh-CN",
+					"Entries": []archiveEntry{{ID: "abc123", Name: article.Name, Title: article.Title, Date: article.Date, DateISO: article.DateISO}},
+				})
+			},
+			want: []string{
+				`<html lang="zh-CN">`,
+				`<main>`,
+				`<ul aria-label="存档文章列表">`,
+				`<time datetime="2024-08-01T08:00:00&#43;08:00">2024-08-01 08:00</time>`,
+				`<a href="abc123.html">`,
+			},
+		},
+		{
+			name: "friends page",
+			run: func(b *bytes.Buffer) error {
+				return friendsPageTemplate.Execute(b, map[string]interface{}{
+					"Lang":     "zh-CN",
+					"Title":    "朋友圈",
+					"Updated":  time.Date(2024, 8, 1, 9, 0, 0, 0, time.FixedZone("CST", 8*3600)),
+					"Articles": []Article{article},
+				})
+			},
+			want: []string{
+				`<html lang="zh-CN">`,
+				`<main>`,
+				`<time datetime="2024-08-01T09:00:00&#43;08:00">2024-08-01 09:00</time>`,
+				`<time datetime="2024-08-01T08:00:00&#43;08:00">2024-08-01 08:00</time>`,
+				`aria-label="博客主页：示例博客"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := tt.run(&b); err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			page := b.String()
+			if !strings.HasPrefix(page, "<!DOCTYPE html>") {
+				t.Errorf("page does not start with a doctype:\n%s", page)
+			}
+			if !strings.Contains(page, `<meta name="color-scheme" content="light dark">`) {
+				t.Errorf("page is missing the shared layout head:\n%s", page)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(page, want) {
+					t.Errorf("page is missing %q:\n%s", want, page)
+				}
+			}
+		})
+	}
+}
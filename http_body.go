@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// 请求时声明支持的压缩格式
+const acceptEncoding = "gzip, deflate, br"
+
+// 解压后响应内容的大小上限，0 表示不限制，由 MAX_RESPONSE_SIZE_KB 设置
+var maxResponseSize int64 = 5 << 20
+
+// 响应内容超过 MAX_RESPONSE_SIZE_KB
+type responseTooLargeError struct {
+	limit int64
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds %d KB", e.limit>>10)
+}
+
+// 按 Content-Encoding 解压响应内容，不认识的编码保持原样
+func decodeResponse(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	var decoded io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		// 304 等没有内容的响应也可能带有 Content-Encoding
+		if resp.ContentLength == 0 || resp.Request.Method == http.MethodHead {
+			return nil
+		}
+		r, err := gzip.NewReader(resp.Body)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error decoding gzip response: %v", err)
+		}
+		decoded = r
+	case "deflate":
+		decoded = newDeflateReader(resp.Body)
+	case "br":
+		decoded = brotli.NewReader(resp.Body)
+	default:
+		return nil
+	}
+
+	resp.Body = &decodedBody{Reader: decoded, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// HTTP 的 deflate 本应是 zlib 格式，但不少服务器发送不带头的原始 deflate 数据，根据前两个字节判断
+func newDeflateReader(body io.Reader) io.Reader {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		if r, err := zlib.NewReader(buffered); err == nil {
+			return r
+		}
+	}
+	return flate.NewReader(buffered)
+}
+
+// 解压后的响应内容，关闭时同时关闭原始连接
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	if c, ok := b.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return b.body.Close()
+}
+
+// 限制响应内容的大小，避免异常的 RSS 把数百 MB 的内容读入内存
+func limitResponse(resp *http.Response) {
+	if maxResponseSize <= 0 {
+		return
+	}
+	resp.Body = &limitedBody{body: resp.Body, remaining: maxResponseSize, limit: maxResponseSize}
+}
+
+// 超过上限时返回 *responseTooLargeError 的响应内容
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// 正好读到上限时，确认后面是否还有内容
+		var probe [1]byte
+		for {
+			n, err := b.body.Read(probe[:])
+			if n > 0 {
+				return 0, &responseTooLargeError{limit: b.limit}
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// 对 http:// 的 RSS 定期尝试 https://，能正常获取并解析时将列表中的地址升级，
+// 健康记录随地址迁移。返回更新后的 RSS 列表
+func upgradeFeedsToHTTPS(config Config, store Storage, lines []string, health map[string]*feedHealth) ([]string, error) {
+	if config.HTTPSProbeInterval <= 0 {
+		return lines, nil
+	}
+
+	blocked, err := loadBlocklist(store)
+	if err != nil {
+		return lines, err
+	}
+
+	fp := gofeed.NewParser()
+	now := time.Now()
+	var edits []feedEdit
+	var changes []feedChange
+
+	for _, spec := range parseFeedList(lines) {
+		if !strings.HasPrefix(spec.URL, "http://") || feedRetired(spec.Options) {
+			continue
+		}
+
+		entry := health[spec.URL]
+		if entry == nil {
+			entry = &feedHealth{URL: spec.URL}
+			health[spec.URL] = entry
+		}
+		if last, err := time.Parse(time.RFC3339, entry.LastHTTPSProbe); err == nil && now.Sub(last) < config.HTTPSProbeInterval {
+			continue
+		}
+		entry.LastHTTPSProbe = now.Format(time.RFC3339)
+
+		httpsURL := "https://" + strings.TrimPrefix(spec.URL, "http://")
+		if _, ok := blocked.match(httpsURL); ok {
+			continue
+		}
+		body, err := fetchFeedBody(nil, blocked, httpsURL, &feedResult{})
+		if err != nil {
+			debugf("HTTPS probe for %s failed: %v", spec.URL, err)
+			continue
+		}
+		_, err = fp.Parse(sanitizeXML(body))
+		body.Close()
+		if err != nil {
+			debugf("HTTPS probe for %s failed: %v", spec.URL, err)
+			continue
+		}
+
+		edits = append(edits, feedEdit{Op: "replace", URL: spec.URL, NewURL: httpsURL})
+		changes = append(changes, feedChange{Type: "https-upgrade", URL: spec.URL, NewURL: httpsURL, Reason: "feed is available over HTTPS"})
+	}
+
+	if len(edits) == 0 {
+		return lines, nil
+	}
+
+	updated, err := applyFeedEdits(lines, edits)
+	if err != nil {
+		return lines, err
+	}
+	if err := store.WriteFeeds(updated); err != nil {
+		// 只提交了 PR 时列表没有变化，继续使用原地址，健康记录和变更记录等 PR 合并后再迁移
+		if feedsProposed(err) {
+			fmt.Printf("%v\n", err)
+			return lines, nil
+		}
+		return lines, err
+	}
+
+	for _, edit := range edits {
+		if entry := health[edit.URL]; entry != nil {
+			delete(health, edit.URL)
+			entry.URL = edit.NewURL
+			health[edit.NewURL] = entry
+		}
+		logWarn(store, "Feed upgraded to HTTPS", "feed", edit.URL, "new", edit.NewURL)
+	}
+
+	return updated, recordFeedChanges(store, changes)
+}
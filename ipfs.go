@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// manifest.json 中一个发布到 IPFS 的文件
+type ipfsEntry struct {
+	CID  string `json:"cid"`
+	Size int    `json:"size"`
+	// 通过 IPFS_GATEWAY 访问的地址
+	URL string `json:"url"`
+}
+
+const pinataAPI = "https://api.pinata.cloud"
+
+// 将 rss_data.json 和已生成的合并订阅发布到 IPFS，CID 记入 manifest.json，并取消固定上一次的版本
+func publishToIPFS(config Config, store Storage) error {
+	if config.IPFSProvider == "" {
+		return nil
+	}
+
+	names := []string{"rss_data.json"}
+	if config.FriendsFeed {
+		names = append(names, "friends.xml")
+	}
+	if config.FriendsJSONFeed {
+		names = append(names, "friends-feed.json")
+	}
+
+	manifest, err := loadManifest(store)
+	if err != nil {
+		return err
+	}
+	previous := manifest.IPFS
+	manifest.IPFS = map[string]ipfsEntry{}
+
+	for _, name := range names {
+		data, err := store.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			continue
+		}
+		cid, err := pinToIPFS(config, name, data)
+		if err != nil {
+			return fmt.Errorf("error pinning %s to IPFS: %v", name, err)
+		}
+		manifest.IPFS[name] = ipfsEntry{CID: cid, Size: len(data), URL: strings.TrimSuffix(config.IPFSGateway, "/") + "/" + cid}
+		debugf("Pinned %s to IPFS: %s", name, cid)
+
+		if old := previous[name].CID; old != "" && old != cid {
+			if err := unpinFromIPFS(config, old); err != nil {
+				logWarn(store, "IPFS unpin error", "cid", old, "err", err)
+			}
+		}
+	}
+	return saveManifest(store, manifest)
+}
+
+// 上传并固定文件，返回 CID
+func pinToIPFS(config Config, name string, data []byte) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return "", err
+	}
+	part.Write(data)
+
+	var endpoint string
+	switch config.IPFSProvider {
+	case "kubo":
+		endpoint = strings.TrimSuffix(config.IPFSAPI, "/") + "/api/v0/add?pin=true&cid-version=1"
+	case "pinata":
+		endpoint = pinataAPI + "/pinning/pinFileToIPFS"
+		form.WriteField("pinataMetadata", fmt.Sprintf(`{"name":"grab-latest-rss/%s"}`, name))
+		form.WriteField("pinataOptions", `{"cidVersion":1}`)
+	default:
+		return "", fmt.Errorf("unknown IPFS_PROVIDER %q, expected kubo or pinata", config.IPFSProvider)
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	respBody, err := ipfsRequest(config, http.MethodPost, endpoint, form.FormDataContentType(), &body)
+	if err != nil {
+		return "", err
+	}
+
+	// Kubo 返回 Hash，Pinata 返回 IpfsHash
+	var result struct {
+		Hash     string
+		IpfsHash string
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("error parsing response: %v", err)
+	}
+	if result.Hash != "" {
+		return result.Hash, nil
+	}
+	if result.IpfsHash != "" {
+		return result.IpfsHash, nil
+	}
+	return "", fmt.Errorf("no CID in response")
+}
+
+// 取消固定不再使用的 CID
+func unpinFromIPFS(config Config, cid string) error {
+	var err error
+	switch config.IPFSProvider {
+	case "kubo":
+		_, err = ipfsRequest(config, http.MethodPost, strings.TrimSuffix(config.IPFSAPI, "/")+"/api/v0/pin/rm?arg="+url.QueryEscape(cid), "", nil)
+	case "pinata":
+		_, err = ipfsRequest(config, http.MethodDelete, pinataAPI+"/pinning/unpin/"+url.PathEscape(cid), "", nil)
+	}
+	return err
+}
+
+// 发送请求到 IPFS 服务，IPFS_TOKEN 作为 Bearer 令牌
+func ipfsRequest(config Config, method string, endpoint string, contentType string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if config.IPFSToken != "" {
+		req.Header.Set("Authorization", "Bearer "+config.IPFSToken)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
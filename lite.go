@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// rss_lite.json 中的文章，只保留移动端小组件显示的字段
+type liteArticle struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Link  string `json:"link"`
+	Date  string `json:"date"`
+}
+
+// 按字符数截断，超出时以省略号结尾，maxRunes 为 0 时不截断
+func truncateRunes(s string, maxRunes int) string {
+	runes := []rune(s)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		return string(runes[:maxRunes]) + "…"
+	}
+	return s
+}
+
+// 写入精简的 rss_lite.json：只有博客名称、标题、链接和日期，名称和标题按 LITE_TEXT_LENGTH 截断，
+// 不包含摘要、封面等补充信息
+func writeLiteArticles(config Config, store Storage, articles []Article) error {
+	lite := make([]liteArticle, len(articles))
+	for i, article := range articles {
+		lite[i] = liteArticle{
+			Name:  truncateRunes(article.Name, config.LiteTextLength),
+			Title: truncateRunes(article.Title, config.LiteTextLength),
+			Link:  article.Link,
+			Date:  article.Date,
+		}
+	}
+
+	jsonData, err := json.Marshal(lite)
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("rss_lite.json", jsonData)
+}
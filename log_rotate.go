@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// 将一批日志追加到 error.log 的现有内容之后，返回新的 error.log 内容。
+// 超过 ERROR_LOG_MAX_SIZE 时按 ERROR_LOG_ROTATE 处理：
+// archive 将现有内容移到 error-<年月>.log（同月多次归档时追加在后面），error.log 从本批日志重新开始；
+// truncate 只保留最新的 ERROR_LOG_KEEP_SIZE，在日志条目之间截断
+func appendErrorLog(config Config, store Storage, existing []byte, message string) ([]byte, error) {
+	entry := []byte(message + "\n\n")
+	content := append(existing, entry...)
+	if config.ErrorLogMaxSize <= 0 || int64(len(content)) <= config.ErrorLogMaxSize || len(existing) == 0 {
+		return content, nil
+	}
+
+	switch config.ErrorLogRotate {
+	case "truncate":
+		return truncateErrorLog(content, config.ErrorLogKeepSize), nil
+	case "archive":
+		name := fmt.Sprintf("error-%s.log", localTime().Format("200601"))
+		archived, err := store.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", name, err)
+		}
+		if err := store.WriteFile(name, append(archived, existing...)); err != nil {
+			return nil, fmt.Errorf("error writing %s: %v", name, err)
+		}
+		return entry, nil
+	}
+	return nil, fmt.Errorf("invalid ERROR_LOG_ROTATE %q, expected archive or truncate", config.ErrorLogRotate)
+}
+
+// 保留最后 keep 字节，从截断处之后的第一个完整条目开始
+func truncateErrorLog(content []byte, keep int64) []byte {
+	if keep <= 0 || int64(len(content)) <= keep {
+		return content
+	}
+	tail := content[int64(len(content))-keep:]
+	if i := bytes.Index(tail, []byte("\n\n")); i >= 0 && i+2 < len(tail) {
+		tail = tail[i+2:]
+	}
+	return append([]byte(nil), tail...)
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 日志输出：标准输出、LOG_FILE 指定的文件，以及存储后端的 error.log（GitHub、COS、S3 等）。
+// 各输出的级别可以分别设置，格式由 LOG_FORMAT 决定（text 或 json）
+var (
+	// 标准输出和日志文件，由 setupLogging 初始化
+	logSinks []slog.Handler
+	// 写入存储后端的最低级别
+	remoteLogLevel slog.Leveler = slog.LevelWarn
+	// 日志格式：text、json
+	logFormat = "text"
+)
+
+// 串行写入日志，避免并发抓取时同时修改 error.log
+var logMu sync.Mutex
+
+// 运行中暂存的 error.log 日志，运行结束时由 flushLogs 一次写入，
+// 避免每条日志都读写一次 error.log（GitHub 后端每次都是一个提交）
+var (
+	pendingLogs   = map[Storage][]string{}
+	pendingStores []Storage
+)
+
+// 将暂存的日志追加到各存储后端的 error.log，每个后端只写入一次
+func flushLogs() {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	for _, store := range pendingStores {
+		if err := store.AppendLog(strings.Join(pendingLogs[store], "\n\n")); err != nil {
+			fmt.Printf("error writing error.log: %v\n", err)
+		}
+	}
+	pendingLogs = map[Storage][]string{}
+	pendingStores = nil
+	if reporter != nil {
+		reporter.flush()
+	}
+}
+
+func init() {
+	logSinks = []slog.Handler{slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo, ReplaceAttr: localTimeAttr})}
+}
+
+// 日志时间使用 TIMEZONE 时区
+func localTimeAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+		a.Value = slog.TimeValue(a.Value.Time().In(timeZone))
+	}
+	return a
+}
+
+// 解析日志级别：debug、info、warn、error，off 表示不输出
+func parseLogLevel(value string) (slog.Level, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, true, nil
+	case "info", "":
+		return slog.LevelInfo, true, nil
+	case "warn", "warning":
+		return slog.LevelWarn, true, nil
+	case "error":
+		return slog.LevelError, true, nil
+	case "off", "none":
+		return 0, false, nil
+	}
+	return 0, false, fmt.Errorf("invalid log level %q", value)
+}
+
+// 按配置初始化日志输出，-v 时标准输出使用 debug 级别
+func setupLogging(config Config) error {
+	logFormat = config.LogFormat
+	if logFormat != "text" && logFormat != "json" {
+		return fmt.Errorf("invalid LOG_FORMAT %q, expected text or json", config.LogFormat)
+	}
+
+	level, enabled, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return err
+	}
+	if verbose {
+		level, enabled = slog.LevelDebug, true
+	}
+
+	var sinks []slog.Handler
+	if enabled {
+		sinks = append(sinks, newLogHandler(os.Stdout, level))
+	}
+
+	if config.LogFile != "" {
+		file, err := os.OpenFile(config.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("error opening log file: %v", err)
+		}
+		sinks = append(sinks, newLogHandler(file, level))
+	}
+
+	remote, remoteEnabled, err := parseLogLevel(config.LogRemoteLevel)
+	if err != nil {
+		return err
+	}
+	if remoteEnabled {
+		remoteLogLevel = remote
+	} else {
+		// 高于所有级别，不写入存储后端
+		remoteLogLevel = slog.LevelError + 1
+	}
+
+	reporter, err = newErrorReporter(config)
+	if err != nil {
+		return err
+	}
+	if reporter != nil {
+		sinks = append(sinks, &reporterHandler{})
+	}
+
+	if config.ActionsAnnotations {
+		sinks = append(sinks, &actionsHandler{})
+	}
+
+	logSinks = sinks
+	return nil
+}
+
+// 按 LOG_FORMAT 创建输出到 w 的 handler
+func newLogHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: localTimeAttr}
+	if logFormat == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// 写入存储后端 error.log 的 handler。text 格式与以前的 error.log 保持一致：
+//
+//	[Mon Jan 2 15:04:2006] [Fetch RSS error] feed=https://example.com/feed err="..."
+type storageLogHandler struct {
+	store Storage
+	attrs []slog.Attr
+}
+
+func (h *storageLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= remoteLogLevel.Level()
+}
+
+func (h *storageLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	var line string
+	if logFormat == "json" {
+		var buf bytes.Buffer
+		handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: localTimeAttr}).WithAttrs(h.attrs)
+		if err := handler.Handle(ctx, record); err != nil {
+			return err
+		}
+		line = strings.TrimRight(buf.String(), "\n")
+	} else {
+		var buf bytes.Buffer
+		// 只用 TextHandler 格式化属性，时间和消息按原来的格式输出
+		attrs := slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+				return slog.Attr{}
+			}
+			return a
+		}}).WithAttrs(h.attrs)
+		if err := attrs.Handle(ctx, record); err != nil {
+			return err
+		}
+		line = fmt.Sprintf("[%s] [%s]", localTime().Format("Mon Jan 2 15:04:2006"), record.Message)
+		if detail := strings.TrimSpace(buf.String()); detail != "" {
+			line += " " + detail
+		}
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	if _, ok := pendingLogs[h.store]; !ok {
+		pendingStores = append(pendingStores, h.store)
+	}
+	pendingLogs[h.store] = append(pendingLogs[h.store], line)
+	return nil
+}
+
+func (h *storageLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &storageLogHandler{store: h.store, attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h *storageLogHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// 同时输出到多个 handler
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			if err := h.Handle(ctx, record.Clone()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// 输出到标准输出、日志文件和 store 的 logger，store 为 nil 时不写入存储后端
+func storeLogger(store Storage) *slog.Logger {
+	handlers := append(fanoutHandler(nil), logSinks...)
+	if store != nil {
+		handlers = append(handlers, &storageLogHandler{store: store})
+	}
+	return slog.New(handlers)
+}
+
+// 记录错误，args 为 slog 风格的键值对，例如 logError(store, "Parse RSS error", "feed", feedURL, "err", err)
+func logError(store Storage, msg string, args ...any) {
+	storeLogger(store).Error(msg, args...)
+}
+
+// 记录需要关注但不是错误的事件，例如 RSS 被停用、文章被隔离
+func logWarn(store Storage, msg string, args ...any) {
+	storeLogger(store).Warn(msg, args...)
+}
+
+// 输出调试信息，只在 -v 或 LOG_LEVEL=debug 时显示
+func debugf(format string, args ...interface{}) {
+	storeLogger(nil).Debug(fmt.Sprintf(format, args...))
+}
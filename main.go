@@ -0,0 +1,629 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// 爬虫数据
+type Article struct {
+	// 域名
+	DomainName string `json:"domainName"`
+	// 博客名称
+	Name string `json:"name"`
+	// 文章标题
+	Title string `json:"title"`
+	// 文章链接
+	Link string `json:"link"`
+	// 文章发布时间，非爬虫原数据，而是格式化后的结果
+	Date string `json:"date"`
+	// 文章发布时间，RFC3339 格式，便于前端本地化展示和精确排序
+	DateISO string `json:"dateIso"`
+	// 博客语言，来自 RSS 的 language 字段，例如 zh-CN
+	Language string `json:"language,omitempty"`
+	// 博客头像，缓存到数据目录 avatars/ 后的地址，或 RSS 列表中 avatar 选项指定的地址
+	Avatar string `json:"avatar,omitempty"`
+	// 博客分类，来自 RSS 列表中的 category 选项
+	Category string `json:"category,omitempty"`
+	// 封面图片，来自 RSS 条目的图片、附件或正文中的第一张图片
+	Cover string `json:"cover,omitempty"`
+	// 提取的全文 content/<id>.json 的地址，只有设置了 fulltext 选项的 RSS 才有
+	Content string `json:"content,omitempty"`
+	// 纯文本摘要，来自文章的 description（没有时使用正文），长度由 SUMMARY_LENGTH 限制
+	Summary string `json:"summary,omitempty"`
+	// 正文中的图片、视频和代码块数量，都没有时省略
+	Media *articleMedia `json:"media,omitempty"`
+
+	// 原始发布时间，仅用于排序，不输出到 JSON
+	published time.Time
+	// 文章所属的 RSS 地址
+	feedURL string
+	// RSS 中的 GUID，用于去重
+	guid string
+	// RSS 中声明的图片（<image>、Atom 的 logo/icon），用作头像
+	feedImage string
+	// RSS 中的博客主页地址
+	siteURL string
+	// RSS 列表中该 RSS 的附加选项
+	options map[string]string
+	// 从 history.json 找回的文章标识，保留上一次发布的文章时使用，见 articleKey
+	key string
+}
+
+// 抓取网页使用的 HTTP 客户端
+var httpClient = &http.Client{Timeout: time.Second * 30}
+
+// 提取域名并加上 https:// 前缀
+func extractDomain(urlStr string) (string, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return "", err
+	}
+	domain := u.Hostname()
+	protocol := "https://"
+	if u.Scheme != "" {
+		protocol = u.Scheme + "://"
+	}
+	fullURL := protocol + domain
+
+	return fullURL, nil
+}
+
+// 规范化语言标签，例如 zh_cn -> zh-CN，用于 HTML lang 属性
+func normalizeLanguage(lang string) string {
+	lang = strings.TrimSpace(strings.ReplaceAll(lang, "_", "-"))
+	if lang == "" {
+		return ""
+	}
+
+	parts := strings.Split(lang, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// 日志和文章日期使用的时区，由 TIMEZONE 设置，默认为中国标准时间 CST，UTC+8
+var timeZone = time.FixedZone("CST", 8*3600)
+
+// TIMEZONE 时区的当前时间
+func localTime() time.Time {
+	return time.Now().In(timeZone)
+}
+
+// 是否输出详细信息，由 -v 开启
+var verbose bool
+
+// 获取 RSS 内容，命中本地缓存时使用 ETag/Last-Modified 发起条件请求。
+// 请求次数（包括重定向）和下载的字节数记入 result，返回的响应体由调用方关闭
+func fetchFeedBody(cache *diskCache, blocked blocklist, feedURL string, result *feedResult) (*feedBody, error) {
+	if err := checkRobots(context.Background(), feedURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, ok := cache.Get("feeds", feedURL)
+	if ok {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	result.Requests++
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每次重定向都是一次额外的请求
+	for r := resp.Request; r.Response != nil; r = r.Response.Request {
+		result.Requests++
+	}
+
+	// 内容未变化，直接使用缓存
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		result.NotModified = true
+		return cachedFeedBody(cached.Body), nil
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		resp.Body.Close()
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	// 重定向到了被屏蔽的地址
+	if entry, ok := blocked.match(resp.Request.URL.String()); ok {
+		resp.Body.Close()
+		return nil, &blockedError{fmt.Sprintf("redirected to %s, %v", resp.Request.URL, entry)}
+	}
+
+	// 只缓存完整的 200 响应
+	if resp.StatusCode != http.StatusOK {
+		cache = nil
+	}
+	return newFeedBody(resp.Body, result, cache, cacheEntry{
+		Key:          feedURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}), nil
+}
+
+// 从 RSS 列表中抓取最新的文章，并按发布时间排序。最多同时抓取 FETCH_CONCURRENCY 个 RSS，
+// 按 feeds 的顺序开始抓取；设置 FETCH_TIMEOUT 时，时间用完后不再开始新的抓取，未抓取的 RSS 在 deferred 中返回
+func fetchRSS(config Config, store Storage, feeds []string) (articles []Article, results []feedResult, deferred []string, err error) {
+	// 本地缓存，用于条件请求
+	cache := openCache(config)
+	defer func() {
+		if err := cache.Evict(); err != nil {
+			fmt.Printf("error evicting cache: %v\n", err)
+		}
+	}()
+
+	// 屏蔽列表
+	blocked, err := loadBlocklist(store)
+	if err != nil {
+		logError(store, "Read blocklist error", "err", err)
+	}
+
+	// 按 RSS 列表的顺序保存结果
+	type fetched struct {
+		result  feedResult
+		article *Article
+		skipped bool
+	}
+	outputs := make([]fetched, len(feeds))
+
+	concurrency := config.FetchConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var deadline time.Time
+	if config.FetchTimeout > 0 {
+		deadline = time.Now().Add(config.FetchTimeout)
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	started := len(feeds)
+	for i, feedURL := range feeds {
+		sem <- struct{}{}
+		// 时间预算用完，已开始的抓取继续完成
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			<-sem
+			started = i
+			deferred = feeds[i:]
+			break
+		}
+		wg.Add(1)
+		go func(i int, feedURL string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			// 最先执行，上报完成前 wg.Wait 不会返回
+			defer reportPanic()
+
+			result, article, ok := fetchLatestArticle(config, store, cache, blocked, feedURL)
+			outputs[i] = fetched{result: result, article: article, skipped: !ok}
+		}(i, feedURL)
+	}
+	wg.Wait()
+
+	if len(deferred) > 0 {
+		logWarn(store, "Fetch time budget exhausted", "budget", config.FetchTimeout, "fetched", started, "deferred", len(deferred))
+	}
+
+	for _, out := range outputs[:started] {
+		if out.skipped {
+			continue
+		}
+		results = append(results, out.result)
+		if out.article != nil {
+			articles = append(articles, *out.article)
+		}
+	}
+
+	sortArticles(articles)
+
+	return articles, results, deferred, nil
+}
+
+// 抓取单个 RSS 的最新一篇文章。被屏蔽列表跳过时 ok 为 false；RSS 没有文章时 article 为 nil
+func fetchLatestArticle(config Config, store Storage, cache *diskCache, blocked blocklist, feedURL string) (result feedResult, article *Article, ok bool) {
+	if entry, ok := blocked.match(feedURL); ok {
+		fmt.Printf("Skipping %s: %v\n", feedURL, entry)
+		return result, nil, false
+	}
+
+	// RSS 解析器，不能在多个 goroutine 间共享
+	fp := gofeed.NewParser()
+
+	// RSS 列表中设置的解析修正，link-xpath 需要保留原文
+	override := parseOverrideFor(feedURL)
+	var raw *bytes.Buffer
+
+	start := time.Now()
+	result = feedResult{URL: feedURL}
+	body, err := fetchFeed(cache, blocked, feedURL, &result)
+	var feed *gofeed.Feed
+	var parseErr error
+	if err == nil {
+		// 边下载边清理 XML 中的非法字符并解析
+		r := sanitizeXML(body)
+		if override != nil && override.linkXPath != nil {
+			raw = new(bytes.Buffer)
+			r = io.TeeReader(r, raw)
+		}
+		feed, parseErr = fp.Parse(r)
+		body.Close()
+		// 下载中途出错导致的解析失败按获取失败处理
+		if parseErr != nil && body.err != nil {
+			err, parseErr = body.err, nil
+		}
+	}
+	result.Duration = time.Since(start)
+	metrics.observeFetch(feedURL, result.Duration, err)
+	debugf("Fetched %s in %v: %d requests, %d bytes, not modified: %v", feedURL, result.Duration.Round(time.Millisecond), result.Requests, result.Bytes, result.NotModified)
+
+	// 获取 RSS 错误，写入日志
+	if err != nil {
+		logError(store, "Get RSS error", "feed", feedURL, "err", err)
+		return result.failed(classifyFetchError(err), err), nil, true
+	}
+
+	// gofeed 无法解析时尝试备用解析方式
+	if parseErr != nil {
+		if fallback, parser, err := parseFeedFallback(fp, blocked, feedURL, &result); err == nil {
+			debugf("Parsed %s with the %s fallback parser after: %v", feedURL, parser, parseErr)
+			feed, parseErr = fallback, nil
+		}
+	}
+
+	if err := parseErr; err != nil {
+		metrics.observeParseFailure(feedURL)
+
+		// 解析 RSS 错误，写入日志
+		logError(store, "Parse RSS error", "feed", feedURL, "err", err)
+		return result.failed(classifyParseError(err), err), nil, true
+	}
+
+	// 使用 feed.Link 作为主网站 URL
+	mainSiteURL := feed.Link
+	if entry, ok := blocked.match(mainSiteURL); ok {
+		fmt.Printf("Skipping %s: site %s %v\n", feedURL, mainSiteURL, entry)
+		return result.failed(errKindBlocked, fmt.Errorf("site %s %v", mainSiteURL, entry)), nil, true
+	}
+
+	// 提取主网站的域名
+	domainName, err := extractDomain(mainSiteURL)
+	if err != nil {
+		logError(store, "Extract domain error", "site", mainSiteURL, "err", err)
+		// 如果提取失败，使用默认值
+		domainName = "unknown"
+	}
+	result.Name = cleanText(feed.Title)
+	result.DomainName = domainName
+	result.Meta = newFeedMeta(feed)
+
+	result.RecentItems = countRecentItems(feed, config.FeedBurstWindow)
+
+	// 只获取最新的一篇文章
+	if len(feed.Items) == 0 {
+		result.Empty = true
+		return result, nil, true
+	}
+	item := feed.Items[0]
+
+	if override != nil {
+		var rawBytes []byte
+		if raw != nil {
+			rawBytes = raw.Bytes()
+		}
+		if err := override.apply(feed, item, rawBytes); err != nil {
+			logError(store, "Parse override error", "feed", feedURL, "err", err)
+		}
+	}
+
+	// 尝试解析不同的时间字段
+	publishedTime, err := itemTime(item)
+
+	// 获取文章时间错误，写入日志，按 DATE_FALLBACK 处理
+	if err != nil {
+		logError(store, "Getting article time error", "feed", feedURL, "title", item.Title, "err", err)
+
+		switch config.DateFallback {
+		case "skip":
+			return result, nil, true
+		case "feed":
+			publishedTime = time.Now()
+			if feed.UpdatedParsed != nil {
+				publishedTime = *feed.UpdatedParsed
+			}
+		default:
+			// 使用当前时间作为文章时间
+			publishedTime = time.Now()
+		}
+	}
+
+	return result, &Article{
+		DomainName: domainName,
+		Name:       result.Name,
+		Title:      cleanText(item.Title),
+		Link:       item.Link,
+
+		// 格式化后的发布时间
+		Date:    formatTime(publishedTime),
+		DateISO: publishedTime.Format(time.RFC3339),
+
+		// 博客语言
+		Language: normalizeLanguage(feed.Language),
+
+		// 文章摘要、封面和媒体数量
+		Summary: articleSummary(item, config.SummaryLength),
+		Cover:   coverFromItem(item),
+		Media:   articleMediaCounts(item),
+
+		published: publishedTime,
+		feedURL:   feedURL,
+		guid:      item.GUID,
+		feedImage: feedImageURL(feed),
+		siteURL:   mainSiteURL,
+	}, true
+}
+
+// 根据发布时间对文章进行排序，最新的文章在最前面
+func sortArticles(articles []Article) {
+	sort.Slice(articles, func(i, j int) bool {
+		return articles[i].published.After(articles[j].published)
+	})
+}
+
+// 第一阶段：抓取完成后立即发布核心数据 rss_data.json
+func publish(config Config, store Storage, articles []Article) error {
+	if err := checkEmptyPublish(config, store, articles); err != nil {
+		return err
+	}
+	// 相对时间以发布时刻为准，常驻模式下保留的旧文章也要重新计算
+	if dateFormat == relativeDateFormat {
+		for i := range articles {
+			articles[i].Date = formatTime(articles[i].published)
+		}
+	}
+	if err := store.SaveArticles(articles); err != nil {
+		return err
+	}
+	metrics.observeSuccess(time.Now())
+	return nil
+}
+
+// 第二阶段：补充文章信息，生成合并订阅、静态页面和存档等附加输出，失败只记录日志
+func publishExtras(config Config, store Storage, articles []Article) {
+	// 精简输出和 CSV 不包含补充信息，先于补充信息写入
+	if config.LiteOutput {
+		if err := writeLiteArticles(config, store, articles); err != nil {
+			logError(store, "Write rss_lite.json error", "err", err)
+		}
+	}
+	if config.CSVOutput {
+		if err := writeArticlesCSV(config, store, articles); err != nil {
+			logError(store, "Write rss_data.csv error", "err", err)
+		}
+	}
+
+	// 补充信息有修改时会重新保存 rss_data.json
+	enrichAndSave(config, store, articles)
+
+	if config.GroupedOutput {
+		if err := writeGroupedArticles(config, store, articles); err != nil {
+			logError(store, "Write grouped data error", "err", err)
+		}
+	}
+
+	if config.FriendsFeed {
+		atom, err := renderFriendsAtom(config, articles)
+		if err == nil {
+			err = store.WriteFile("friends.xml", atom)
+		}
+		if err != nil {
+			logError(store, "Write friends.xml error", "err", err)
+		}
+	}
+
+	if config.FriendsJSONFeed {
+		jsonFeed, err := renderFriendsJSONFeed(config, articles)
+		if err == nil {
+			err = store.WriteFile("friends-feed.json", jsonFeed)
+		}
+		if err != nil {
+			logError(store, "Write friends-feed.json error", "err", err)
+		}
+	}
+
+	if config.FriendsPage {
+		page, err := renderFriendsPage(config, articles)
+		if err == nil {
+			err = store.WriteFile("friends.html", page)
+		}
+		if err != nil {
+			logError(store, "Write friends.html error", "err", err)
+		}
+	}
+
+	if config.ArchiveJSON {
+		if err := writeArticleArchive(config, store, articles); err != nil {
+			logError(store, "Write archive.json error", "err", err)
+		}
+	}
+
+	if config.ArchivePages {
+		if err := writeArchivePages(config, store, articles); err != nil {
+			logError(store, "Write archive pages error", "err", err)
+		}
+	}
+
+	// 在所有输出生成之后发布到 IPFS
+	if err := publishToIPFS(config, store); err != nil {
+		logError(store, "Publish to IPFS error", "err", err)
+	}
+
+	// 最后更新 DNS TXT 记录，表示本次发布已完成
+	if err := updateDNSBeacon(config, store); err != nil {
+		logError(store, "Update DNS beacon error", "err", err)
+	}
+}
+
+// 抓取所有 RSS 并发布，即默认的 fetch 子命令
+func runFetch(config Config, store Storage) error {
+	// 检查 API 配额，不足时降级
+	checkStorageBudget(store)
+	// 合并本次运行的写入，提前返回时也提交已有的写入
+	beginStorageBatch(store)
+	defer func() {
+		if err := commitStorageBatch(store); err != nil {
+			fmt.Printf("%v\n", err)
+		}
+	}()
+	// 提前返回时也写入本次运行的日志
+	defer flushLogs()
+
+	// 从存储后端读取 RSS
+	feedLines, err := store.ReadFeeds()
+	if err != nil {
+		logError(store, "Read RSS feeds error", "err", err)
+		notifyFatal(config, store, fmt.Sprintf("Error reading RSS feeds: %v", err))
+		return fmt.Errorf("error reading RSS feeds: %v", err)
+	}
+	reportFeedListErrors(store, feedLines)
+	setFeedProxies(parseFeedList(feedLines))
+	setRenderedFeeds(parseFeedList(feedLines))
+
+	// RSS 健康状况，跳过已停用的 RSS
+	health, err := loadFeedHealth(store)
+	if err != nil {
+		logError(store, "Read feed health error", "err", err)
+		health = map[string]*feedHealth{}
+	}
+
+	// 尝试将 http:// 的 RSS 升级到 https://
+	feedLines, err = upgradeFeedsToHTTPS(config, store, feedLines, health)
+	if err != nil {
+		logError(store, "HTTPS upgrade error", "err", err)
+	}
+	urls := feedURLs(parseFeedList(feedLines))
+	setParseOverrides(parseFeedList(feedLines))
+
+	// 抓取 RSS
+	runStart := time.Now()
+	// 退役的 RSS 不再抓取，urls 仍包含它们，以保留健康状况和元数据
+	live := skipRetiredFeeds(parseFeedList(feedLines), urls)
+	articles, results, deferred, err := fetchRSS(config, store, prioritizeFeeds(parseFeedList(feedLines), health, skipDisabledFeeds(health, live)))
+	if err != nil {
+		logError(store, "Fetch RSS error", "err", err)
+		notifyFatal(config, store, fmt.Sprintf("Error fetching RSS feeds: %v", err))
+		return fmt.Errorf("error fetching RSS feeds: %v", err)
+	}
+
+	// 没有文章和因时间预算未抓取的 RSS 保留上一次的文章
+	articles = keepPreviousArticles(store, health, results, deferred, articles)
+
+	// 附加 RSS 列表中的选项
+	attachFeedOptions(parseFeedList(feedLines), articles)
+	assignCategories(config, store, articles)
+
+	// 按 RSS 列表中的规则改写文章链接
+	articles = rewriteArticleLinks(store, parseFeedList(feedLines), articles)
+
+	// 域名与历史记录不同的 RSS 暂不发布
+	articles, results = checkDomainChanges(config, store, parseFeedList(feedLines), health, articles, results)
+
+	// 镜像与主站合并为一条
+	articles = consolidateMirrors(parseFeedList(feedLines), health, articles)
+
+	// 突然重新发布大量文章的 RSS 本次不发布
+	articles = guardFeedBursts(config, store, articles, results)
+
+	// 同一篇文章只保留一次
+	articles = dedupArticles(articles)
+
+	// 隔离链接不安全的文章
+	articles = quarantineUnsafeArticles(config, store, articles)
+
+	// 第一阶段：立即发布核心数据
+	publishErr := publish(config, store, articles)
+	if publishErr != nil {
+		logError(store, "Save data error", "err", publishErr)
+		notifyFatal(config, store, fmt.Sprintf("Error saving data: %v", publishErr))
+	}
+
+	// 第二阶段：RSS 健康状况和运行统计（核心数据发布失败时也记录）
+	if err := updateFeedHealth(config, store, health, results, urls); err != nil {
+		logError(store, "Update feed health error", "err", err)
+	}
+	if err := writeRunStats(store, runStart, results); err != nil {
+		logError(store, "Write stats error", "err", err)
+	}
+	if err := writeFeedMeta(store, results, urls); err != nil {
+		logError(store, "Write feed metadata error", "err", err)
+	}
+	if err := updateChangelog(store, parseFeedList(feedLines), results); err != nil {
+		logError(store, "Update changelog error", "err", err)
+	}
+	if publishErr != nil {
+		if err := appendRunLog(store, "", runStart, results, len(articles), 0, publishErr); err != nil {
+			logError(store, "Write run log error", "err", err)
+		}
+		return fmt.Errorf("error saving data: %v", publishErr)
+	}
+
+	// 补充信息和附加输出
+	publishExtras(config, store, articles)
+
+	// 状态徽章
+	if config.Badges {
+		if err := writeBadges(store, health, live, articles); err != nil {
+			logError(store, "Write badges error", "err", err)
+		}
+	}
+
+	// 记入历史，通过发件箱发送运行摘要和新文章通知
+	fresh := recordAndNotify(config, store, articles, results)
+
+	// 运行摘要
+	if err := appendRunLog(store, "", runStart, results, len(articles), fresh, nil); err != nil {
+		logError(store, "Write run log error", "err", err)
+	}
+	// 审计记录
+	if err := appendAudit(config, store, "", feedLines, len(articles)); err != nil {
+		logError(store, "Write audit log error", "err", err)
+	}
+
+	flushLogs()
+	if err := commitStorageBatch(store); err != nil {
+		notifyFatal(config, store, fmt.Sprintf("Error saving data: %v", err))
+		return fmt.Errorf("error saving data: %v", err)
+	}
+	// 同时使用多个后端时检查各副本是否一致
+	verifyStorageCopies(config, store)
+	reportStorageTargets(config, store)
+	reportStorageBudget(store)
+	fmt.Println("Stop writing code and go ride a road bike now!")
+	return nil
+}
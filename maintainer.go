@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 当前操作的维护者，来自 MAINTAINER 或发出命令的 Issue 评论者，为空时不署名。
+// 署名写入提交信息、feed_changes.json（进而出现在 CHANGELOG.md 中）和 audit.log
+var currentMaintainer string
+
+// 按配置设置当前维护者，设置了 MAINTAINERS 时必须在名单中
+func configureMaintainer(config Config) error {
+	currentMaintainer = strings.TrimSpace(config.Maintainer)
+	if currentMaintainer != "" && !maintainerAllowed(config, currentMaintainer) {
+		return fmt.Errorf("MAINTAINER %s is not listed in MAINTAINERS", currentMaintainer)
+	}
+	return nil
+}
+
+// 是否为 MAINTAINERS 中的维护者（GitHub 用户名不区分大小写），没有设置 MAINTAINERS 时不限制
+func maintainerAllowed(config Config, name string) bool {
+	if strings.TrimSpace(config.Maintainers) == "" {
+		return true
+	}
+	for _, m := range strings.Split(config.Maintainers, ",") {
+		if strings.EqualFold(strings.TrimSpace(m), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// 在提交信息末尾加上维护者署名
+func attributeCommit(message string) string {
+	if currentMaintainer == "" {
+		return message
+	}
+	return strings.TrimRight(message, "\n") + "\n\nMaintainer: " + currentMaintainer + "\n"
+}
+
+// GitHub Actions issue_comment 事件中用到的字段
+type issueCommentEvent struct {
+	Issue struct {
+		Number int `json:"number"`
+	} `json:"issue"`
+	Comment struct {
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"comment"`
+}
+
+// 执行 Issue 评论中的命令，评论者必须在 MAINTAINERS 中。每行一条命令：
+//
+//	/feeds add|remove|replace|set <url> [...]
+//	/fetch
+//
+// 评论中的其他文字作为修改原因，与评论者一起记录
+func runIssueCommand(config Config, store Storage) error {
+	eventPath := os.Getenv("GITHUB_EVENT_PATH")
+	if eventPath == "" {
+		return fmt.Errorf("GITHUB_EVENT_PATH is not set, run this from an issue_comment workflow")
+	}
+	data, err := os.ReadFile(eventPath)
+	if err != nil {
+		return err
+	}
+	var event issueCommentEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("error parsing %s: %v", eventPath, err)
+	}
+
+	login := event.Comment.User.Login
+	if strings.TrimSpace(config.Maintainers) == "" {
+		return fmt.Errorf("MAINTAINERS must be set to accept issue commands")
+	}
+	if login == "" || !maintainerAllowed(config, login) {
+		return fmt.Errorf("%s is not a maintainer, ignoring the comment on issue #%d", login, event.Issue.Number)
+	}
+	currentMaintainer = login
+
+	var edits [][]string
+	var reason []string
+	fetch := false
+	for _, line := range strings.Split(event.Comment.Body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "/feeds "):
+			edits = append(edits, strings.Fields(strings.TrimPrefix(line, "/feeds ")))
+		case line == "/fetch":
+			fetch = true
+		case line != "" && !strings.HasPrefix(line, ">"):
+			// 引用的内容不作为原因
+			reason = append(reason, line)
+		}
+	}
+	if len(edits) == 0 && !fetch {
+		return fmt.Errorf("no /feeds or /fetch command in the comment on issue #%d", event.Issue.Number)
+	}
+
+	for _, args := range edits {
+		command := "/feeds " + strings.Join(args, " ")
+		if len(reason) > 0 {
+			args = append([]string{"-reason", strings.Join(reason, " ")}, args...)
+		}
+		if err := runFeedsCommand(store, args); err != nil {
+			return fmt.Errorf("%s: %v", command, err)
+		}
+		fmt.Printf("Applied %s for %s\n", command, login)
+	}
+	if fetch {
+		return runFetch(config, store)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 发布清单 manifest.json，记录最近一次发布的时间和各发布渠道的结果，供外部监控校验
+type publishManifest struct {
+	// 最近一次更新的时间，RFC3339
+	Updated string `json:"updated"`
+	// 发布到 IPFS 的文件，键为文件名
+	IPFS map[string]ipfsEntry `json:"ipfs,omitempty"`
+}
+
+// 读取 manifest.json，不存在时返回空清单
+func loadManifest(store Storage) (*publishManifest, error) {
+	data, err := store.ReadFile("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	manifest := &publishManifest{}
+	if data != nil {
+		if err := json.Unmarshal(data, manifest); err != nil {
+			return nil, fmt.Errorf("error parsing manifest.json: %v", err)
+		}
+	}
+	return manifest, nil
+}
+
+// 更新时间并写回 manifest.json
+func saveManifest(store Storage, manifest *publishManifest) error {
+	manifest.Updated = time.Now().Format(time.RFC3339)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("manifest.json", data)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html"
+)
+
+// 文章中的图片、视频和代码块数量，前端据此显示图片文章、视频文章、代码较多的文章等图标
+type articleMedia struct {
+	Images int `json:"images,omitempty"`
+	Videos int `json:"videos,omitempty"`
+	Code   int `json:"code,omitempty"`
+}
+
+// 嵌入视频的常见网站，<iframe> 指向这些网站时计为视频
+var videoEmbedHosts = []string{
+	"youtube.com", "youtube-nocookie.com", "youtu.be", "vimeo.com",
+	"bilibili.com", "youku.com", "v.qq.com", "ixigua.com", "douyin.com",
+}
+
+// 统计文章中的媒体数量，使用正文（没有时使用 description）和附件，都没有时返回 nil
+func articleMediaCounts(item *gofeed.Item) *articleMedia {
+	content := item.Content
+	if content == "" {
+		content = item.Description
+	}
+	media := countMedia(content)
+	for _, enclosure := range item.Enclosures {
+		switch {
+		case strings.HasPrefix(enclosure.Type, "video/"):
+			media.Videos++
+		case isImageEnclosure(enclosure) && !strings.Contains(content, enclosure.URL):
+			media.Images++
+		}
+	}
+	if media == (articleMedia{}) {
+		return nil
+	}
+	return &media
+}
+
+// 统计 HTML 片段中的图片、视频和代码块。图片跳过内嵌的 data: 图片，
+// 代码块按 <pre> 计算，行内的 <code> 不计
+func countMedia(content string) articleMedia {
+	var media articleMedia
+	if !strings.Contains(content, "<") {
+		return media
+	}
+
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return media
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		switch string(name) {
+		case "img":
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "src" && !strings.HasPrefix(strings.TrimSpace(string(val)), "data:") {
+					media.Images++
+					break
+				}
+			}
+		case "video":
+			media.Videos++
+		case "iframe":
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "src" && isVideoEmbed(string(val)) {
+					media.Videos++
+					break
+				}
+			}
+		case "pre":
+			media.Code++
+		}
+	}
+}
+
+// <iframe> 的地址是否为视频网站的播放器
+func isVideoEmbed(src string) bool {
+	u, err := url.Parse(strings.TrimSpace(src))
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, videoHost := range videoEmbedHosts {
+		if host == videoHost || strings.HasSuffix(host, "."+videoHost) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 运行指标，以 Prometheus 文本格式通过 /metrics 提供
+type metricsRegistry struct {
+	mu sync.Mutex
+
+	feedsFetched  int64
+	parseFailures map[string]int64
+	httpErrors    map[string]int64
+	// RSS 地址 -> 最近一次抓取耗时
+	fetchDurations map[string]time.Duration
+	lastSuccess    time.Time
+}
+
+var metrics = &metricsRegistry{
+	parseFailures:  map[string]int64{},
+	httpErrors:     map[string]int64{},
+	fetchDurations: map[string]time.Duration{},
+}
+
+// 记录一次 RSS 抓取
+func (m *metricsRegistry) observeFetch(feedURL string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.feedsFetched++
+	m.fetchDurations[feedURL] = duration
+	if err != nil {
+		m.httpErrors[feedURL]++
+	}
+}
+
+// 记录一次 RSS 解析失败
+func (m *metricsRegistry) observeParseFailure(feedURL string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parseFailures[feedURL]++
+}
+
+// 记录最近一次成功发布的时间
+func (m *metricsRegistry) observeSuccess(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastSuccess = t
+}
+
+// 转义 Prometheus 标签值
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// 按 RSS 地址排序输出带 feed 标签的指标
+func writeFeedMetric(w io.Writer, name string, values map[string]float64) {
+	feeds := make([]string, 0, len(values))
+	for feed := range values {
+		feeds = append(feeds, feed)
+	}
+	sort.Strings(feeds)
+	for _, feed := range feeds {
+		fmt.Fprintf(w, "%s{feed=\"%s\"} %g\n", name, escapeLabel(feed), values[feed])
+	}
+}
+
+// 以 Prometheus 文本格式输出所有指标
+func (m *metricsRegistry) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP grab_feeds_fetched_total Number of feed fetch attempts.")
+	fmt.Fprintln(w, "# TYPE grab_feeds_fetched_total counter")
+	fmt.Fprintf(w, "grab_feeds_fetched_total %d\n", m.feedsFetched)
+
+	fmt.Fprintln(w, "# HELP grab_feed_http_errors_total Number of failed feed HTTP requests.")
+	fmt.Fprintln(w, "# TYPE grab_feed_http_errors_total counter")
+	httpErrors := map[string]float64{}
+	for feed, n := range m.httpErrors {
+		httpErrors[feed] = float64(n)
+	}
+	writeFeedMetric(w, "grab_feed_http_errors_total", httpErrors)
+
+	fmt.Fprintln(w, "# HELP grab_feed_parse_failures_total Number of feeds that could not be parsed.")
+	fmt.Fprintln(w, "# TYPE grab_feed_parse_failures_total counter")
+	parseFailures := map[string]float64{}
+	for feed, n := range m.parseFailures {
+		parseFailures[feed] = float64(n)
+	}
+	writeFeedMetric(w, "grab_feed_parse_failures_total", parseFailures)
+
+	fmt.Fprintln(w, "# HELP grab_feed_fetch_duration_seconds Duration of the last fetch of each feed.")
+	fmt.Fprintln(w, "# TYPE grab_feed_fetch_duration_seconds gauge")
+	durations := map[string]float64{}
+	for feed, d := range m.fetchDurations {
+		durations[feed] = d.Seconds()
+	}
+	writeFeedMetric(w, "grab_feed_fetch_duration_seconds", durations)
+
+	fmt.Fprintln(w, "# HELP grab_last_success_timestamp_seconds Unix time of the last successful publish.")
+	fmt.Fprintln(w, "# TYPE grab_last_success_timestamp_seconds gauge")
+	var lastSuccess int64
+	if !m.lastSuccess.IsZero() {
+		lastSuccess = m.lastSuccess.Unix()
+	}
+	fmt.Fprintf(w, "grab_last_success_timestamp_seconds %d\n", lastSuccess)
+}
+
+// /metrics
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.writeTo(w)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+)
+
+// 镜像 RSS 与主站 RSS 合并为一条：同一博客在多个域名发布相同内容时（例如 .com 和 .cn），
+// 在镜像的一行加上 mirror-of=<主站 RSS 地址>，两者只保留最新的一篇文章并署名为主站。
+// 标题相同时优先使用主站的文章，主站抓取失败时由镜像补上
+func consolidateMirrors(specs []feedSpec, health map[string]*feedHealth, articles []Article) []Article {
+	listed := map[string]bool{}
+	for _, spec := range specs {
+		listed[spec.URL] = true
+	}
+
+	// 镜像 RSS -> 主站 RSS
+	canonical := map[string]string{}
+	for _, spec := range specs {
+		target := spec.Options["mirror-of"]
+		if target == "" || target == spec.URL {
+			continue
+		}
+		if !listed[target] {
+			debugf("Ignoring mirror-of=%s for %s: not in the feed list", target, spec.URL)
+			continue
+		}
+		canonical[spec.URL] = target
+	}
+	if len(canonical) == 0 {
+		return articles
+	}
+
+	// 主站的署名，优先使用本次抓取的结果
+	names := map[string][2]string{}
+	for feedURL, entry := range health {
+		if entry.Name != "" || entry.DomainName != "" {
+			names[feedURL] = [2]string{entry.Name, entry.DomainName}
+		}
+	}
+	for _, article := range articles {
+		if _, ok := canonical[article.feedURL]; !ok {
+			names[article.feedURL] = [2]string{article.Name, article.DomainName}
+		}
+	}
+
+	// 主站 RSS -> 保留的文章在结果中的位置
+	kept := map[string]int{}
+	// 结果中的文章是否来自镜像
+	var fromMirror []bool
+	result := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		target, mirror := canonical[article.feedURL]
+		if mirror {
+			if name, ok := names[target]; ok {
+				article.Name, article.DomainName = name[0], name[1]
+			}
+			article.feedURL = target
+		}
+
+		i, seen := kept[article.feedURL]
+		if !seen {
+			kept[article.feedURL] = len(result)
+			result = append(result, article)
+			fromMirror = append(fromMirror, mirror)
+			continue
+		}
+
+		current := result[i]
+		sameTitle := strings.EqualFold(strings.TrimSpace(current.Title), strings.TrimSpace(article.Title))
+		if sameTitle && fromMirror[i] && !mirror || !sameTitle && article.published.After(current.published) {
+			result[i] = article
+			fromMirror[i] = mirror
+		}
+	}
+	return result
+}
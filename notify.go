@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Telegram 单条消息的长度上限
+const telegramMaxMessage = 4096
+
+// 通过 Telegram 机器人发送消息，未配置 TELEGRAM_BOT_TOKEN / TELEGRAM_CHAT_ID 时不发送
+func sendTelegram(config Config, text string) error {
+	if config.TelegramBotToken == "" || config.TelegramChatID == "" {
+		return nil
+	}
+
+	// 在换行处截断：MarkdownV2 的转义、链接和 HTML 标签都不跨行，从中间截断会使 Telegram 无法解析
+	if runes := []rune(text); len(runes) > telegramMaxMessage {
+		cut := string(runes[:telegramMaxMessage-1])
+		if i := strings.LastIndex(cut, "\n"); i > 0 {
+			cut = cut[:i+1]
+		}
+		text = cut + "…"
+	}
+
+	message := map[string]interface{}{
+		"chat_id":                  config.TelegramChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+	if config.TelegramParseMode != "" {
+		message["parse_mode"] = config.TelegramParseMode
+	}
+	err := postTelegram(config, message)
+	// 自定义模板生成的格式有误时改为纯文本重发，避免整条消息丢失
+	if err != nil && message["parse_mode"] != nil && strings.Contains(err.Error(), "can't parse entities") {
+		fmt.Printf("%v, resending as plain text\n", err)
+		delete(message, "parse_mode")
+		err = postTelegram(config, message)
+	}
+	return err
+}
+
+// 调用 Telegram 的 sendMessage 接口
+func postTelegram(config Config, message map[string]interface{}) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post("https://api.telegram.org/bot"+config.TelegramBotToken+"/sendMessage", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		// url.Error 中的地址包含令牌，只保留底层错误
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("error sending Telegram message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("error sending Telegram message: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// 运行摘要：新文章和抓取失败的 RSS
+func runSummaryText(config Config, fresh []Article, results []feedResult) (string, error) {
+	summary := notifySummary{Fresh: fresh}
+	for _, result := range results {
+		if result.Err != nil {
+			summary.Failed = append(summary.Failed, result)
+		}
+	}
+	return telegramSummary(config, summary)
+}
+
+// 发生致命错误时立即通知，不经过发件箱
+func notifyFatal(config Config, store Storage, message string) {
+	sendAlert(config, store, "fatal", message)
+}
+
+// 按 alert 模板发送即时提醒
+func sendAlert(config Config, store Storage, kind string, message string) {
+	if config.TelegramBotToken == "" || config.TelegramChatID == "" {
+		return
+	}
+	text, err := alertText(config, kind, message)
+	if err != nil {
+		logError(store, "Telegram notification error", "err", err)
+		return
+	}
+	if err := sendTelegram(config, text); err != nil {
+		logError(store, "Telegram notification error", "err", err)
+	}
+}
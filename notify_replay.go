@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// 解析 notify 子命令
+func runNotifyCommand(config Config, store Storage, args []string) error {
+	if len(args) == 0 || args[0] != "replay" {
+		return fmt.Errorf("usage: notify replay -since <date> [-channel telegram|webhook]")
+	}
+
+	fs := flag.NewFlagSet("notify replay", flag.ExitOnError)
+	since := fs.String("since", "", "replay articles first seen on or after this date (2006-01-02 or RFC3339)")
+	channel := fs.String("channel", "", "only replay to this channel: telegram or webhook (default all configured)")
+	fs.Parse(args[1:])
+
+	if *since == "" {
+		return fmt.Errorf("usage: notify replay -since <date> [-channel telegram|webhook]")
+	}
+	sinceTime, err := parseReplaySince(*since)
+	if err != nil {
+		return err
+	}
+	switch *channel {
+	case "", "telegram", "webhook":
+	default:
+		return fmt.Errorf("unknown channel %q, expected telegram or webhook", *channel)
+	}
+
+	return replayNotifications(config, store, sinceTime, *channel)
+}
+
+// 解析 -since：日期按 TIMEZONE 的零点计算，也可以是 RFC3339 时间
+func parseReplaySince(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, timeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -since %q, expected 2006-01-02 or RFC3339", value)
+	}
+	return t, nil
+}
+
+// 从 history.json 中取出 since 之后首次发现的文章，重新生成通知事件并通过发件箱发送。
+// Webhook 事件的 ID 与当初的相同，接收方已处理过的可以据此去重；发件箱中同 ID 的旧记录会被替换
+func replayNotifications(config Config, store Storage, since time.Time, channel string) error {
+	history, err := loadHistory(store)
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	var articles []Article
+	// history.json 中最近发现的在前，按发现时间先后补发
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		firstSeen, err := time.Parse(time.RFC3339, entry.FirstSeen)
+		if err != nil || firstSeen.Before(since) {
+			continue
+		}
+		keys = append(keys, entry.ID)
+		articles = append(articles, historyArticle(entry))
+	}
+	if len(articles) == 0 {
+		fmt.Printf("No articles first seen since %s\n", since.Format(time.RFC3339))
+		return nil
+	}
+
+	var events []outboxEvent
+	if (channel == "" || channel == "telegram") && config.TelegramBotToken != "" && config.TelegramChatID != "" {
+		text, err := telegramSummary(config, notifySummary{
+			Fresh:  articles,
+			Replay: true,
+			Since:  since.In(timeZone).Format("2006-01-02 15:04"),
+		})
+		if err != nil {
+			return err
+		}
+		events = append(events, outboxEvent{ID: summaryEventID("replay", keys, nil), Channel: "telegram", Payload: text})
+	}
+	if channel == "" || channel == "webhook" {
+		for _, u := range webhookURLs(config) {
+			for i, article := range articles {
+				event, err := webhookEvent(config, u, keys[i], article)
+				if err != nil {
+					return err
+				}
+				events = append(events, event)
+			}
+		}
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("no notification channel configured")
+	}
+
+	outbox, err := loadOutbox(store)
+	if err != nil {
+		return err
+	}
+
+	// 去掉同 ID 的旧记录（已发送或已放弃的），使事件重新入队
+	replayed := map[string]bool{}
+	for _, event := range events {
+		replayed[event.ID] = true
+	}
+	kept := outbox[:0]
+	for _, event := range outbox {
+		if !replayed[event.ID] {
+			kept = append(kept, event)
+		}
+	}
+
+	outbox, added := enqueueEvents(kept, events)
+	if err := saveOutbox(store, outbox); err != nil {
+		return fmt.Errorf("error writing outbox.json: %v", err)
+	}
+	outbox = deliverOutbox(config, store, outbox)
+	if err := saveOutbox(store, outbox); err != nil {
+		return fmt.Errorf("error writing outbox.json: %v", err)
+	}
+
+	failed := 0
+	for _, event := range outbox {
+		if replayed[event.ID] && event.SentAt == "" {
+			failed++
+		}
+	}
+	fmt.Printf("Replayed %d articles as %d notifications, %d failed (will be retried on the next run)\n", len(articles), added, failed)
+	return nil
+}
+
+// 由历史记录还原文章，只包含历史中保存的字段
+func historyArticle(entry historyEntry) Article {
+	article := Article{
+		Name:    entry.Name,
+		Title:   entry.Title,
+		Link:    entry.Link,
+		DateISO: entry.DateISO,
+		feedURL: entry.FeedURL,
+	}
+	if published, err := time.Parse(time.RFC3339, entry.DateISO); err == nil {
+		article.Date = formatTime(published)
+		article.published = published
+	}
+	if domain, err := extractDomain(entry.Link); err == nil {
+		article.DomainName = domain
+	}
+	return article
+}
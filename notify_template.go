@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// 运行摘要模板（telegram.tmpl）的数据
+type notifySummary struct {
+	// 新文章
+	Fresh []Article
+	// 抓取失败的 RSS
+	Failed []feedResult
+	// 是否为 notify replay 补发，Since 为补发的起始时间
+	Replay bool
+	Since  string
+}
+
+// 即时提醒模板（alert.tmpl）的数据
+type notifyAlert struct {
+	// 提醒类型：fatal（读取列表、抓取或保存失败）、domain（域名变更）
+	Kind    string
+	Message string
+}
+
+// 内置模板，按语言区分。模板中的 esc 按渠道的消息格式转义，纯文本时原样输出
+var builtinNotifyTemplates = map[string]map[string]string{
+	"zh-CN": {
+		"telegram": `{{if .Replay}}补发通知：{{esc .Since}} 以来的新文章 {{len .Fresh}} 篇
+{{else}}友链抓取完成：新文章 {{len .Fresh}} 篇，失败 RSS {{len .Failed}} 个
+{{end}}{{if .Fresh}}
+新文章：
+{{range .Fresh}}• {{esc .Name}}：{{esc .Title}}
+{{esc .Link}}
+{{end}}{{end}}{{if .Failed}}
+失败：
+{{range .Failed}}• {{esc .URL}}：{{esc .Err.Error}}
+{{end}}{{end}}`,
+		"alert": `{{if eq .Kind "domain"}}友链域名变更，需要人工复核：{{else if eq .Kind "divergence"}}存储副本不一致：{{else if eq .Kind "mirror"}}存储镜像写入失败：{{else}}友链抓取失败：{{end}}
+{{esc .Message}}`,
+	},
+	"en": {
+		"telegram": `{{if .Replay}}Replayed notifications: {{len .Fresh}} new articles since {{esc .Since}}
+{{else}}Fetch finished: {{len .Fresh}} new articles, {{len .Failed}} failed feeds
+{{end}}{{if .Fresh}}
+New articles:
+{{range .Fresh}}• {{esc .Name}}: {{esc .Title}}
+{{esc .Link}}
+{{end}}{{end}}{{if .Failed}}
+Failed:
+{{range .Failed}}• {{esc .URL}}: {{esc .Err.Error}}
+{{end}}{{end}}`,
+		"alert": `{{if eq .Kind "domain"}}Feed domain changed, needs review:{{else if eq .Kind "divergence"}}Storage copies differ:{{else if eq .Kind "mirror"}}Storage mirror writes failed:{{else}}Feed fetch failed:{{end}}
+{{esc .Message}}`,
+	},
+}
+
+// MarkdownV2 中需要转义的字符
+var markdownV2Escaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// 模板函数，esc 按消息格式转义：MarkdownV2、HTML，其他格式原样输出
+func notifyTemplateFuncs(format string) template.FuncMap {
+	esc := func(s string) string { return s }
+	switch strings.ToLower(format) {
+	case "markdownv2":
+		esc = markdownV2Escaper.Replace
+	case "html":
+		esc = html.EscapeString
+	case "json":
+		esc = func(s string) string {
+			data, _ := json.Marshal(s)
+			return string(data[1 : len(data)-1])
+		}
+	}
+
+	return template.FuncMap{
+		"esc":      esc,
+		"markdown": markdownV2Escaper.Replace,
+		"html":     html.EscapeString,
+		// 输出 JSON 值，用于 Webhook 模板，例如 {"text": {{json .Article.Title}}}
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"truncate": func(n int, s string) string {
+			if runes := []rune(s); len(runes) > n {
+				return string(runes[:n]) + "…"
+			}
+			return s
+		},
+	}
+}
+
+// 加载通知模板：依次查找 NOTIFY_TEMPLATES 目录中的 <name>.tmpl，都没有时使用 NOTIFY_LANGUAGE 对应的内置模板。
+// 没有可用模板时返回 nil
+func loadNotifyTemplate(config Config, format string, names ...string) (*template.Template, error) {
+	for _, name := range names {
+		if config.NotifyTemplates == "" {
+			break
+		}
+		data, err := os.ReadFile(filepath.Join(config.NotifyTemplates, name+".tmpl"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s: %v", name, err)
+		}
+		tmpl, err := template.New(name).Funcs(notifyTemplateFuncs(format)).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template %s: %v", name, err)
+		}
+		return tmpl, nil
+	}
+
+	builtin := builtinNotifyTemplates["zh-CN"]
+	if strings.HasPrefix(strings.ToLower(config.NotifyLanguage), "en") {
+		builtin = builtinNotifyTemplates["en"]
+	}
+	for _, name := range names {
+		if text, ok := builtin[name]; ok {
+			return template.Must(template.New(name).Funcs(notifyTemplateFuncs(format)).Parse(text)), nil
+		}
+	}
+	return nil, nil
+}
+
+// 按模板生成消息
+func renderNotifyTemplate(tmpl *template.Template, data interface{}) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error rendering template %s: %v", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// 生成 Telegram 运行摘要
+func telegramSummary(config Config, summary notifySummary) (string, error) {
+	tmpl, err := loadNotifyTemplate(config, config.TelegramParseMode, "telegram")
+	if err != nil {
+		return "", err
+	}
+	return renderNotifyTemplate(tmpl, summary)
+}
+
+// 生成即时提醒
+func alertText(config Config, kind string, message string) (string, error) {
+	tmpl, err := loadNotifyTemplate(config, config.TelegramParseMode, "alert")
+	if err != nil {
+		return "", err
+	}
+	return renderNotifyTemplate(tmpl, notifyAlert{Kind: kind, Message: message})
+}
+
+// 按模板生成 Webhook 请求体，依次查找 webhook-<域名>.tmpl、webhook.tmpl，
+// 例如为 hooks.slack.com 提供 Slack blocks，为 qyapi.weixin.qq.com 提供企业微信文本卡片。
+// 没有模板时返回 nil，使用默认的 JSON 事件
+func webhookBody(config Config, target string, payload webhookPayload) ([]byte, error) {
+	var names []string
+	if u, err := url.Parse(target); err == nil && u.Hostname() != "" {
+		names = append(names, "webhook-"+u.Hostname())
+	}
+	names = append(names, "webhook")
+
+	tmpl, err := loadNotifyTemplate(config, "json", names...)
+	if err != nil || tmpl == nil {
+		return nil, err
+	}
+	body, err := renderNotifyTemplate(tmpl, payload)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid([]byte(body)) {
+		return nil, fmt.Errorf("template %s did not produce valid JSON", tmpl.Name())
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// OPML 文档，用于在阅读器之间导入导出订阅
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Created string        `xml:"head>dateCreated"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+// OPML 中的一条订阅，导入时也可以是包含订阅的分组
+type opmlOutline struct {
+	Type     string        `xml:"type,attr"`
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline,omitempty"`
+}
+
+// 将 RSS 列表导出为 OPML，分组写入 category，不包含退役的 RSS
+func exportOPML(store Storage, w io.Writer) error {
+	lines, err := store.ReadFeeds()
+	if err != nil {
+		return err
+	}
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Title:   "Grab-latest-RSS feeds",
+		Created: time.Now().Format(time.RFC1123Z),
+	}
+	for _, spec := range parseFeedList(lines) {
+		// 退役的博客不再订阅
+		if feedRetired(spec.Options) {
+			continue
+		}
+		outline := opmlOutline{
+			Type:     "rss",
+			Text:     normalizedHost(spec.URL),
+			XMLURL:   spec.URL,
+			Category: spec.Options["tier"],
+		}
+		if name := spec.Options["name"]; name != "" {
+			outline.Text = name
+		}
+		if category := spec.Options["category"]; category != "" {
+			outline.Category = category
+		}
+		doc.Body = append(doc.Body, outline)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding OPML: %v", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// 将 OPML 转换为 RSS 列表：text（或 title）与主机名不同时写入 name 选项，
+// category 属性或所在分组的名称写入 category 选项
+func parseOPML(data []byte) ([]string, error) {
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing OPML: %v", err)
+	}
+
+	var lines []string
+	var walk func(outlines []opmlOutline, group string)
+	walk = func(outlines []opmlOutline, group string) {
+		for _, outline := range outlines {
+			text := outline.Text
+			if text == "" {
+				text = outline.Title
+			}
+			if outline.XMLURL == "" {
+				walk(outline.Outlines, text)
+				continue
+			}
+
+			options := map[string]string{}
+			if text != "" && text != normalizedHost(outline.XMLURL) {
+				options["name"] = text
+			}
+			if category := outline.Category; category != "" {
+				options["category"] = category
+			} else if group != "" {
+				options["category"] = group
+			}
+			lines = append(lines, formatFeedLine(outline.XMLURL, options))
+		}
+	}
+	walk(doc.Body, "")
+	return lines, nil
+}
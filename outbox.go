@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 发送失败的事件最多尝试的次数
+const outboxMaxAttempts = 10
+
+// 已发送的事件保留多久，用于去重
+const outboxRetention = 7 * 24 * time.Hour
+
+// 发件箱中的一条通知，保存在 outbox.json 中
+type outboxEvent struct {
+	// 事件 ID，同一条通知的 ID 不变，重复入队时忽略
+	ID string `json:"id"`
+	// 发送渠道：telegram、webhook
+	Channel string `json:"channel"`
+	// Webhook 地址
+	Target string `json:"target,omitempty"`
+	// Telegram 消息文本或 Webhook 的 JSON
+	Payload string `json:"payload"`
+	// 入队时间，RFC3339
+	Created string `json:"created"`
+	// 已尝试发送的次数和最近一次的错误
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError,omitempty"`
+	// 发送成功的时间，RFC3339，未发送时为空
+	SentAt string `json:"sentAt,omitempty"`
+}
+
+// 根据渠道和内容生成稳定的事件 ID
+func outboxEventID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Telegram 汇总的事件 ID，由新文章的标识和失败的 RSS 地址决定（与顺序无关），
+// 同一批新文章再次汇总时 ID 相同，不会重复入队
+func summaryEventID(kind string, keys []string, failed []string) string {
+	keys = append([]string(nil), keys...)
+	failed = append([]string(nil), failed...)
+	sort.Strings(keys)
+	sort.Strings(failed)
+	parts := append([]string{"telegram", kind}, keys...)
+	parts = append(parts, "failed")
+	return outboxEventID(append(parts, failed...)...)
+}
+
+// 读取 outbox.json，文件不存在时返回 nil
+func loadOutbox(store Storage) ([]outboxEvent, error) {
+	data, err := store.ReadFile("outbox.json")
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var events []outboxEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("error parsing outbox.json: %v", err)
+	}
+	return events, nil
+}
+
+// 写回 outbox.json
+func saveOutbox(store Storage, events []outboxEvent) error {
+	jsonData, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("outbox.json", jsonData)
+}
+
+// 将事件加入发件箱，ID 已存在（未发送或已发送）的事件忽略
+func enqueueEvents(outbox []outboxEvent, events []outboxEvent) ([]outboxEvent, int) {
+	known := map[string]bool{}
+	for _, event := range outbox {
+		known[event.ID] = true
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	added := 0
+	for _, event := range events {
+		if known[event.ID] {
+			continue
+		}
+		known[event.ID] = true
+		event.Created = now
+		outbox = append(outbox, event)
+		added++
+	}
+	return outbox, added
+}
+
+// 发送一条事件
+func deliverEvent(config Config, event outboxEvent) error {
+	switch event.Channel {
+	case "telegram":
+		return sendTelegram(config, event.Payload)
+	case "webhook":
+		return postWebhook(event.Target, event.ID, []byte(event.Payload))
+	}
+	return fmt.Errorf("unknown channel %q", event.Channel)
+}
+
+// 发送所有未发送的事件，成功的标记为已发送；超过尝试次数的放弃，过期的已发送记录清理掉
+func deliverOutbox(config Config, store Storage, outbox []outboxEvent) []outboxEvent {
+	now := time.Now()
+	cutoff := now.Add(-outboxRetention).Format(time.RFC3339)
+
+	kept := outbox[:0]
+	for _, event := range outbox {
+		if event.SentAt != "" {
+			if event.SentAt >= cutoff {
+				kept = append(kept, event)
+			}
+			continue
+		}
+
+		event.Attempts++
+		if err := deliverEvent(config, event); err != nil {
+			event.LastError = err.Error()
+			logError(store, "Notification error", "channel", event.Channel, "id", event.ID, "attempt", event.Attempts, "err", err)
+			if event.Attempts >= outboxMaxAttempts {
+				logWarn(store, "Notification dropped", "channel", event.Channel, "id", event.ID, "attempts", event.Attempts)
+				continue
+			}
+		} else {
+			event.LastError = ""
+			event.SentAt = now.Format(time.RFC3339)
+		}
+		kept = append(kept, event)
+	}
+	return kept
+}
+
+// 记入历史并发送通知。通知先写入发件箱 outbox.json，再保存历史，最后逐条发送并标记：
+// 保存历史前中断时，下次运行会重新发现同样的新文章，但事件 ID 相同不会重复入队；
+// 发送前中断时，未发送的事件会在下次运行时补发。返回新文章的数量
+func recordAndNotify(config Config, store Storage, articles []Article, results []feedResult) int {
+	history, fresh, err := mergeHistory(config, store, articles)
+	if err != nil {
+		logError(store, "Update history error", "err", err)
+	}
+
+	var events []outboxEvent
+	if config.TelegramBotToken != "" && config.TelegramChatID != "" {
+		text, err := runSummaryText(config, fresh, results)
+		if err != nil {
+			logError(store, "Telegram notification error", "err", err)
+		} else {
+			keys := make([]string, len(fresh))
+			for i, article := range fresh {
+				keys[i] = articleKey(article)
+			}
+			var failed []string
+			for _, result := range results {
+				if result.Err != nil {
+					failed = append(failed, result.URL)
+				}
+			}
+			events = append(events, outboxEvent{ID: summaryEventID("run", keys, failed), Channel: "telegram", Payload: text})
+		}
+	}
+	hooks, err := webhookEvents(config, fresh)
+	if err != nil {
+		logError(store, "Webhook error", "err", err)
+	}
+	events = append(events, hooks...)
+
+	outbox, err := loadOutbox(store)
+	if err != nil {
+		// 发件箱无法读取时不能保证去重，本次不发送，历史也不保存，下次运行重新判断
+		logError(store, "Read outbox error", "err", err)
+		return len(fresh)
+	}
+
+	outbox, added := enqueueEvents(outbox, events)
+	if added > 0 {
+		if err := saveOutbox(store, outbox); err != nil {
+			logError(store, "Write outbox error", "err", err)
+			return len(fresh)
+		}
+	}
+
+	if history != nil {
+		if err := saveHistory(store, history); err != nil {
+			logError(store, "Update history error", "err", err)
+		}
+	}
+
+	if len(outbox) == 0 {
+		return len(fresh)
+	}
+	if err := saveOutbox(store, deliverOutbox(config, store, outbox)); err != nil {
+		logError(store, "Write outbox error", "err", err)
+	}
+	return len(fresh)
+}
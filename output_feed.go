@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// Atom 文档
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Link      atomLink     `xml:"link"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Author    atomAuthor   `xml:"author"`
+	Summary   *atomSummary `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomSummary struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// 将所有朋友的最新文章合并为一个 Atom 订阅 friends.xml
+func renderFriendsAtom(config Config, articles []Article) ([]byte, error) {
+	feed := atomFeed{
+		ID:      "urn:grab-latest-rss:friends",
+		Title:   config.FriendsFeedTitle,
+		Updated: time.Now().Format(time.RFC3339),
+	}
+	if config.FeedBaseURL != "" {
+		feed.ID = config.FeedBaseURL + "friends.xml"
+		feed.Links = []atomLink{{Href: config.FeedBaseURL + "friends.xml", Rel: "self", Type: "application/atom+xml"}}
+	}
+	if len(articles) > 0 {
+		feed.Updated = articles[0].DateISO
+	}
+
+	for _, article := range articles {
+		entry := atomEntry{
+			ID:        article.Link,
+			Title:     article.Title,
+			Link:      atomLink{Href: article.Link, Rel: "alternate"},
+			Published: article.DateISO,
+			Updated:   article.DateISO,
+			Author:    atomAuthor{Name: article.Name, URI: article.DomainName},
+		}
+		if guid := articleGUID(article); guid != "" {
+			entry.ID = guid
+		}
+		if article.Summary != "" {
+			entry.Summary = &atomSummary{Type: "text", Text: article.Summary}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// JSON Feed 1.1 文档，https://www.jsonfeed.org/version/1.1/
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Language      string           `json:"language,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// 将所有朋友的最新文章合并为 JSON Feed 1.1 文档 friends-feed.json
+func renderFriendsJSONFeed(config Config, articles []Article) ([]byte, error) {
+	feed := jsonFeed{
+		Version:  "https://jsonfeed.org/version/1.1",
+		Title:    config.FriendsFeedTitle,
+		Language: config.SiteLanguage,
+		Items:    make([]jsonFeedItem, 0, len(articles)),
+	}
+	if config.FeedBaseURL != "" {
+		feed.FeedURL = config.FeedBaseURL + "friends-feed.json"
+	}
+
+	for _, article := range articles {
+		item := jsonFeedItem{
+			ID:            article.Link,
+			URL:           article.Link,
+			Title:         article.Title,
+			ContentText:   article.Summary,
+			Image:         article.Cover,
+			DatePublished: article.DateISO,
+			Authors:       []jsonFeedAuthor{{Name: article.Name, URL: article.DomainName, Avatar: article.Avatar}},
+			Language:      article.Language,
+		}
+		if guid := articleGUID(article); guid != "" {
+			item.ID = guid
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
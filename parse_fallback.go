@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html"
+)
+
+// gofeed 解析失败后依次尝试的备用解析方式
+var fallbackParsers = []struct {
+	name  string
+	parse func(fp *gofeed.Parser, data []byte) (*gofeed.Feed, error)
+}{
+	{"json", parseJSONFeedFallback},
+	{"lenient", parseLenientFeed},
+}
+
+// gofeed 无法解析时重新获取同一地址，依次尝试备用解析方式，返回成功的解析方式名称。
+// 流式解析不保留原文，因此只有格式有问题的 RSS 才会多一次请求
+func parseFeedFallback(fp *gofeed.Parser, blocked blocklist, feedURL string, result *feedResult) (*gofeed.Feed, string, error) {
+	body, err := fetchFeed(nil, blocked, feedURL, result)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := io.ReadAll(sanitizeXML(body))
+	body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, p := range fallbackParsers {
+		if feed, err := p.parse(fp, data); err == nil {
+			return feed, p.name, nil
+		}
+	}
+	return nil, "", fmt.Errorf("no fallback parser could read the feed")
+}
+
+// JSON Feed 前后有多余内容时（JSONP 包装、PHP 警告等）截取其中的 JSON 对象再解析
+func parseJSONFeedFallback(fp *gofeed.Parser, data []byte) (*gofeed.Feed, error) {
+	start := bytes.IndexByte(data, '{')
+	end := bytes.LastIndexByte(data, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object found")
+	}
+	object := data[start : end+1]
+	if !bytes.Contains(object, []byte("jsonfeed.org/version")) {
+		return nil, fmt.Errorf("not a JSON Feed")
+	}
+	return fp.Parse(bytes.NewReader(object))
+}
+
+// HTML 分词器把 <title> 的内容按原文读取到 </title> 为止，没有闭合的 <title> 会吞掉后面的文章，
+// 因此解析前改为普通元素名
+var titleTag = regexp.MustCompile(`(?i)<(/?)title\b`)
+
+// 需要读取文本内容的元素，键为小写的元素名
+var lenientFields = map[string]bool{
+	"feed-title":      true,
+	"link":            true,
+	"guid":            true,
+	"id":              true,
+	"pubdate":         true,
+	"published":       true,
+	"dc:date":         true,
+	"updated":         true,
+	"lastbuilddate":   true,
+	"description":     true,
+	"summary":         true,
+	"content":         true,
+	"content:encoded": true,
+}
+
+// 用 HTML 分词器宽松地解析 RSS 2.0、RSS 1.0（RDF）和 Atom。
+// 未转义的 &、未定义的实体、没有闭合的标签等 XML 错误不会中断解析，只提取标题、链接、时间和内容
+func parseLenientFeed(_ *gofeed.Parser, data []byte) (*gofeed.Feed, error) {
+	z := html.NewTokenizer(bytes.NewReader(titleTag.ReplaceAll(data, []byte("<${1}feed-title"))))
+	z.AllowCDATA(true)
+
+	feed := &gofeed.Feed{}
+	// 当前的文章，位于 <item> 或 <entry> 之外时为 nil
+	var item *gofeed.Item
+	// 正在读取文本的元素
+	var field string
+	var text strings.Builder
+	// <image> 中的 title、link 不是博客的
+	inImage := false
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				attrs[string(key)] = strings.TrimSpace(string(val))
+			}
+
+			switch tag {
+			case "rss":
+				feed.FeedType, feed.FeedVersion = "rss", attrs["version"]
+			case "rdf:rdf":
+				feed.FeedType, feed.FeedVersion = "rss", "1.0"
+			case "feed":
+				feed.FeedType = "atom"
+			case "image":
+				inImage = true
+			case "item", "entry":
+				item = &gofeed.Item{}
+				// RSS 1.0 的文章地址
+				if about := attrs["rdf:about"]; about != "" {
+					item.Link = about
+				}
+			case "link":
+				// Atom 的 <link href="..."/>，只使用 alternate 链接
+				if href := attrs["href"]; href != "" {
+					if rel := attrs["rel"]; rel == "" || rel == "alternate" {
+						if item != nil {
+							item.Link = href
+						} else if feed.Link == "" {
+							feed.Link = href
+						}
+					}
+				}
+			}
+
+			if tt == html.StartTagToken && lenientFields[tag] && !inImage {
+				// 前一个元素没有闭合时保存已读到的内容
+				if field != "" {
+					setLenientField(feed, item, field, strings.TrimSpace(text.String()))
+				}
+				field = tag
+				text.Reset()
+			}
+
+		case html.TextToken:
+			if field != "" {
+				text.Write(z.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == field:
+				setLenientField(feed, item, field, strings.TrimSpace(text.String()))
+				field = ""
+			case tag == "image":
+				inImage = false
+			case (tag == "item" || tag == "entry") && item != nil:
+				if field != "" {
+					setLenientField(feed, item, field, strings.TrimSpace(text.String()))
+					field = ""
+				}
+				if item.Title != "" || item.Link != "" {
+					feed.Items = append(feed.Items, item)
+				}
+				item = nil
+			}
+		}
+	}
+
+	if feed.FeedType == "" {
+		return nil, fmt.Errorf("no rss, rdf:RDF or feed element found")
+	}
+	if len(feed.Items) == 0 && feed.Title == "" {
+		return nil, fmt.Errorf("no feed content found")
+	}
+	if t, err := parseTime(feed.Updated); err == nil {
+		feed.UpdatedParsed = &t
+	}
+	return feed, nil
+}
+
+// 保存宽松解析读到的元素内容，item 为 nil 时属于博客本身
+func setLenientField(feed *gofeed.Feed, item *gofeed.Item, field, value string) {
+	if value == "" {
+		return
+	}
+
+	if item == nil {
+		switch field {
+		case "feed-title":
+			if feed.Title == "" {
+				feed.Title = value
+			}
+		case "link":
+			if feed.Link == "" {
+				feed.Link = value
+			}
+		case "description", "summary":
+			feed.Description = value
+		case "updated", "lastbuilddate", "dc:date":
+			feed.Updated = value
+		}
+		return
+	}
+
+	switch field {
+	case "feed-title":
+		item.Title = value
+	case "link":
+		item.Link = value
+	case "guid", "id":
+		item.GUID = value
+		// 没有 <link> 时使用链接形式的 guid
+		if item.Link == "" && strings.HasPrefix(value, "http") {
+			item.Link = value
+		}
+	case "pubdate", "published", "dc:date":
+		item.Published = value
+	case "updated":
+		item.Updated = value
+	case "description", "summary":
+		item.Description = value
+	case "content", "content:encoded":
+		item.Content = value
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/mmcdole/gofeed"
+)
+
+// 为生成器有缺陷的 RSS 设置的解析修正，来自 RSS 列表中的 date-from-title、link-xpath 选项，
+// 在 gofeed 解析之后作用于最新的一篇文章
+type parseOverride struct {
+	// 从标题中提取发布时间，第一个分组（没有分组时为整个匹配）是时间，匹配的部分从标题中去掉
+	dateFromTitle *regexp.Regexp
+	// 相对于 <item> 或 <entry> 元素的 XPath，取得的文本或属性值作为文章链接
+	linkXPath *xpath.Expr
+}
+
+var (
+	overrideMu sync.Mutex
+	// RSS 地址 -> 解析修正
+	parseOverrides = map[string]*parseOverride{}
+)
+
+// 第一篇文章的元素，与 gofeed 的 Items[0] 对应
+var firstItemXPath = xpath.MustCompile("(//*[local-name()='item' or local-name()='entry'])[1]")
+
+// 编译 date-from-title 选项
+func compileDateFromTitle(value string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %v", err)
+	}
+	return re, nil
+}
+
+// 编译 link-xpath 选项
+func compileLinkXPath(value string) (*xpath.Expr, error) {
+	expr, err := xpath.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath: %v", err)
+	}
+	return expr, nil
+}
+
+// 按 RSS 列表设置各 RSS 的解析修正，格式错误已由 validateFeedList 报告
+func setParseOverrides(specs []feedSpec) {
+	overrides := map[string]*parseOverride{}
+	for _, spec := range specs {
+		var o parseOverride
+		if value := spec.Options["date-from-title"]; value != "" {
+			o.dateFromTitle, _ = compileDateFromTitle(value)
+		}
+		if value := spec.Options["link-xpath"]; value != "" {
+			o.linkXPath, _ = compileLinkXPath(value)
+		}
+		if o.dateFromTitle != nil || o.linkXPath != nil {
+			overrides[spec.URL] = &o
+		}
+	}
+
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	parseOverrides = overrides
+}
+
+// RSS 的解析修正，没有时返回 nil
+func parseOverrideFor(feedURL string) *parseOverride {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	return parseOverrides[feedURL]
+}
+
+// 修正最新一篇文章的时间和链接。raw 为清理后的 RSS 原文，只在设置了 link-xpath 时需要
+func (o *parseOverride) apply(feed *gofeed.Feed, item *gofeed.Item, raw []byte) error {
+	if o.dateFromTitle != nil {
+		if m := o.dateFromTitle.FindStringSubmatchIndex(item.Title); m != nil {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			// 交给 itemTime 按 timeFormats 解析
+			item.Published = item.Title[start:end]
+			item.PublishedParsed = nil
+			item.Title = strings.TrimSpace(item.Title[:m[0]] + item.Title[m[1]:])
+		}
+	}
+
+	if o.linkXPath != nil {
+		doc, err := xmlquery.Parse(bytes.NewReader(raw))
+		if err != nil {
+			return fmt.Errorf("link-xpath: %v", err)
+		}
+		node := xmlquery.QuerySelector(doc, firstItemXPath)
+		if node == nil {
+			return fmt.Errorf("link-xpath: no item found")
+		}
+		target := xmlquery.QuerySelector(node, o.linkXPath)
+		if target == nil {
+			return fmt.Errorf("link-xpath matched nothing")
+		}
+		link := strings.TrimSpace(target.InnerText())
+		ref, err := url.Parse(link)
+		if err != nil || link == "" {
+			return fmt.Errorf("link-xpath: invalid link %q", link)
+		}
+		if base, err := url.Parse(feed.Link); err == nil {
+			ref = base.ResolveReference(ref)
+		}
+		item.Link = ref.String()
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+)
+
+// 只读运行时的配置：不发送通知，不修改本地缓存
+func readOnlyConfig(config Config) Config {
+	config.TelegramBotToken = ""
+	config.WebhookURLs = ""
+	config.IPFSProvider = ""
+	config.DNSBeaconProvider = ""
+	config.CacheDir = "off"
+	return config
+}
+
+// 用另一份 RSS 列表以只读方式完整运行一次，输出将要发布的文章数据和统计，
+// 便于在提交大规模的列表调整前评估结果
+func runPreview(config Config, store Storage, feedsFile string) error {
+	lines, err := readFeedsFromFile(feedsFile)
+	if err != nil {
+		return err
+	}
+
+	preview := newReadOnlyStorage(store, lines)
+	// 与 -dry-run 一样，预览时不上报错误
+	reporter = nil
+	if err := runFetch(readOnlyConfig(config), preview); err != nil {
+		return err
+	}
+	return preview.printWrites()
+}
+
+// 输出只读运行中将要写入的内容：JSON 文件格式化后输出，其他文件只输出名称和大小
+func (s *readOnlyStorage) printWrites() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.feedsChanged {
+		fmt.Println("\n==> rss_feeds.txt <==")
+		for _, line := range s.feeds {
+			fmt.Println(line)
+		}
+	}
+
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := s.files[name]
+		if path.Ext(name) != ".json" {
+			fmt.Printf("\n==> %s (%d bytes) <==\n", name, len(data))
+			continue
+		}
+
+		var out bytes.Buffer
+		if err := json.Indent(&out, data, "", "  "); err != nil {
+			return fmt.Errorf("error formatting %s: %v", name, err)
+		}
+		fmt.Printf("\n==> %s <==\n", name)
+		out.WriteTo(os.Stdout)
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// RSS 的 priority 选项，数字越大越先抓取，默认 0
+func feedPriority(options map[string]string) int {
+	if n, err := strconv.Atoi(options["priority"]); err == nil {
+		return n
+	}
+	return 0
+}
+
+// 按优先级和历史可靠性排列抓取顺序，FETCH_TIMEOUT 用完时重要、稳定的 RSS 已经抓取完毕：
+// priority 大的在前；同一优先级中正常的在前、连续失败次数多的在后，再按平均耗时从快到慢。
+// 没有历史记录的 RSS 视为正常且最快，排序稳定，其余情况保持 RSS 列表的顺序
+func prioritizeFeeds(specs []feedSpec, health map[string]*feedHealth, urls []string) []string {
+	priority := map[string]int{}
+	for _, spec := range specs {
+		priority[spec.URL] = feedPriority(spec.Options)
+	}
+
+	ordered := append([]string(nil), urls...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if priority[a] != priority[b] {
+			return priority[a] > priority[b]
+		}
+		ha, hb := health[a], health[b]
+		if ha == nil || hb == nil {
+			return ha == nil && hb != nil
+		}
+		if ha.ConsecutiveFailures != hb.ConsecutiveFailures {
+			return ha.ConsecutiveFailures < hb.ConsecutiveFailures
+		}
+		return ha.AverageLatencyMs < hb.AverageLatencyMs
+	})
+	return ordered
+}
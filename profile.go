@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 内置的配置方案，每个方案是一组环境变量的默认值
+var builtinProfiles = map[string]map[string]string{
+	// 本地调试：读取本地 RSS 列表，数据写入本地目录，日志只输出到标准输出，不上报错误
+	"local": {
+		"STORAGE_BACKEND":    "local",
+		"LOG_REMOTE_LEVEL":   "off",
+		"ERROR_REPORT_LEVEL": "off",
+	},
+	// GitHub Actions 等持续集成环境：RSS 列表和日志保存在 GitHub，文章数据上传到 COS
+	"ci": {
+		"STORAGE_BACKEND":  "github",
+		"LOG_REMOTE_LEVEL": "warn",
+	},
+}
+
+// 应用配置方案：先读取当前目录下的 .env.<name>，再使用内置方案的默认值。
+// 与 -config 一样，已设置的环境变量优先，因此可以在方案的基础上单独覆盖某一项
+func applyProfile(name string) error {
+	file := ".env." + name
+	_, err := os.Stat(file)
+	hasFile := err == nil
+	if hasFile {
+		if err := loadEnvFile(file); err != nil {
+			return err
+		}
+	}
+
+	defaults, ok := builtinProfiles[name]
+	if !ok {
+		if hasFile {
+			return nil
+		}
+		var names []string
+		for name := range builtinProfiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown profile %q, available: %s (or create %s)", name, strings.Join(names, ", "), file)
+	}
+	for key, value := range defaults {
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	// FETCH_PROXY 指定的全局代理，未设置时使用 HTTP_PROXY、HTTPS_PROXY 环境变量
+	fetchProxy *url.URL
+
+	proxyMu sync.Mutex
+	// RSS 列表中 proxy 选项指定的代理，按主机名匹配，nil 表示直连
+	hostProxies = map[string]*url.URL{}
+)
+
+// 解析代理地址，支持 http://、https://、socks5://、socks5h://
+func parseProxyURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q", value)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, fmt.Errorf("invalid proxy %q, expected http://, https:// or socks5://", value)
+}
+
+// 按 RSS 列表的 proxy 选项设置各主机的代理，proxy=off 表示不使用全局代理。
+// 同一主机的全文、封面、头像等请求也使用该代理
+func setFeedProxies(specs []feedSpec) {
+	proxies := map[string]*url.URL{}
+	for _, spec := range specs {
+		value := spec.Options["proxy"]
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(spec.URL)
+		if err != nil {
+			continue
+		}
+		if value == "off" {
+			proxies[strings.ToLower(u.Hostname())] = nil
+			continue
+		}
+		// 格式错误已由 validateFeedList 报告
+		if proxy, err := parseProxyURL(value); err == nil {
+			proxies[strings.ToLower(u.Hostname())] = proxy
+		}
+	}
+
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	hostProxies = proxies
+}
+
+// 选择请求使用的代理：RSS 列表中的 proxy 选项优先，其次是 FETCH_PROXY 和代理环境变量
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	proxyMu.Lock()
+	proxy, ok := hostProxies[strings.ToLower(req.URL.Hostname())]
+	proxyMu.Unlock()
+	if ok {
+		return proxy, nil
+	}
+	if fetchProxy != nil {
+		return fetchProxy, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
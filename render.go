@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// 渲染服务的地址，见 RENDER_SERVICE
+	renderService string
+	// 渲染服务的请求客户端，超时由 RENDER_TIMEOUT 设置
+	renderHTTPClient = &http.Client{Timeout: time.Minute}
+
+	renderMu sync.Mutex
+	// RSS 列表中设置了 render=true 的 RSS
+	renderedFeeds = map[string]bool{}
+)
+
+// 按配置设置渲染服务
+func configureRender(config Config) error {
+	renderService = config.RenderService
+	renderHTTPClient.Timeout = config.RenderTimeout
+	if renderService != "" && !isHTTPURL(strings.ReplaceAll(renderService, "{url}", "x")) {
+		return fmt.Errorf("RENDER_SERVICE must be an http:// or https:// URL")
+	}
+	return nil
+}
+
+// 按 RSS 列表的 render 选项设置需要渲染的 RSS，其余 RSS 不经过渲染服务
+func setRenderedFeeds(specs []feedSpec) {
+	feeds := map[string]bool{}
+	for _, spec := range specs {
+		if render, _ := strconv.ParseBool(spec.Options["render"]); render {
+			feeds[spec.URL] = true
+		}
+	}
+
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	renderedFeeds = feeds
+}
+
+// RSS 是否需要通过渲染服务抓取
+func feedRendered(feedURL string) bool {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	return renderedFeeds[feedURL]
+}
+
+// 通过无头浏览器渲染服务抓取由 JavaScript 生成的 RSS。RENDER_SERVICE 中含有 {url} 时
+// 以 GET 请求替换后的地址（例如 prerender），否则 POST {"url": ...}（例如 browserless 的 /content）。
+// 渲染结果不缓存，每次都完整抓取
+func fetchRenderedFeed(feedURL string, result *feedResult) (*feedBody, error) {
+	if renderService == "" {
+		return nil, fmt.Errorf("render=true requires RENDER_SERVICE")
+	}
+	// 渲染服务仍会访问博客，同样遵守 robots.txt
+	if err := checkRobots(context.Background(), feedURL); err != nil {
+		return nil, err
+	}
+
+	var req *http.Request
+	var err error
+	if strings.Contains(renderService, "{url}") {
+		req, err = http.NewRequest(http.MethodGet, strings.ReplaceAll(renderService, "{url}", url.QueryEscape(feedURL)), nil)
+	} else {
+		payload, _ := json.Marshal(map[string]string{"url": feedURL})
+		req, err = http.NewRequest(http.MethodPost, renderService, bytes.NewReader(payload))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Requests++
+	resp, err := renderHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("render service: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	reader := io.Reader(resp.Body)
+	if maxResponseSize > 0 {
+		reader = io.LimitReader(resp.Body, maxResponseSize+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("render service: %v", err)
+	}
+	if maxResponseSize > 0 && int64(len(data)) > maxResponseSize {
+		return nil, &responseTooLargeError{limit: maxResponseSize}
+	}
+	result.Bytes += int64(len(data))
+	return cachedFeedBody(unwrapRenderedFeed(data)), nil
+}
+
+// 浏览器把 XML 或 JSON 显示为页面中的文本，渲染服务返回的是包着它的 HTML。
+// 页面文本以 XML 或 JSON 开头时取出文本，否则返回原始 HTML，由解析器或宽松解析处理
+func unwrapRenderedFeed(data []byte) []byte {
+	if !looksLikeHTML(string(data)) {
+		return data
+	}
+
+	var text strings.Builder
+	z := html.NewTokenizer(bytes.NewReader(data))
+	skip := 0
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			content := strings.TrimSpace(text.String())
+			if strings.HasPrefix(content, "<?xml") || strings.HasPrefix(content, "<rss") ||
+				strings.HasPrefix(content, "<feed") || strings.HasPrefix(content, "<rdf:RDF") || strings.HasPrefix(content, "{") {
+				return []byte(content)
+			}
+			return data
+		case html.StartTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			if tag := string(name); tag == "script" || tag == "style" || tag == "head" {
+				if tt == html.StartTagToken {
+					skip++
+				} else if skip > 0 {
+					skip--
+				}
+			}
+		case html.TextToken:
+			if skip == 0 {
+				text.Write(z.Text())
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+// RSS 的 state 选项：retired 表示博客已停更或关闭。
+// 退役的 RSS 不再抓取，也不出现在 rss_data.json、OPML 等当前友链中，
+// 已收录的文章仍保留在存档和历史记录中，feed_health.json、feeds.json 保留最后的记录；
+// blogroll.json 记录退役日期和 farewell 选项中的告别语，CHANGELOG.md 记录一条退役
+func feedRetired(options map[string]string) bool {
+	return options["state"] == "retired"
+}
+
+// 去掉退役的 RSS，返回需要抓取的地址
+func skipRetiredFeeds(specs []feedSpec, urls []string) []string {
+	retired := map[string]bool{}
+	for _, spec := range specs {
+		if feedRetired(spec.Options) {
+			retired[spec.URL] = true
+		}
+	}
+
+	var live []string
+	for _, feedURL := range urls {
+		if !retired[feedURL] {
+			live = append(live, feedURL)
+		}
+	}
+	return live
+}
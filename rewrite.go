@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 文章链接的改写规则
+type rewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// 解析 RSS 列表中的 rewrite 选项，格式与 sed 相同：s<分隔符><正则><分隔符><替换><分隔符>，
+// 多条规则用 ; 连接，分隔符可以是任意字符，替换中可以用 $1 引用分组，例如：
+//
+//	rewrite=s#^http://#https://#;s#/amp/?$##
+func parseRewriteRules(value string) ([]rewriteRule, error) {
+	var rules []rewriteRule
+	rest := value
+	for rest != "" {
+		if len(rest) < 2 || rest[0] != 's' {
+			return nil, fmt.Errorf("invalid rewrite rule %q, expected s#pattern#replacement#", rest)
+		}
+		delim := rest[1]
+
+		parts := make([]string, 0, 2)
+		var part strings.Builder
+		i := 2
+		for ; i < len(rest) && len(parts) < 2; i++ {
+			switch {
+			case rest[i] == '\\' && i+1 < len(rest) && rest[i+1] == delim:
+				// 转义的分隔符
+				part.WriteByte(delim)
+				i++
+			case rest[i] == delim:
+				parts = append(parts, part.String())
+				part.Reset()
+			default:
+				part.WriteByte(rest[i])
+			}
+		}
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("unterminated rewrite rule %q", rest)
+		}
+
+		pattern, err := regexp.Compile(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite pattern %q: %v", parts[0], err)
+		}
+		rules = append(rules, rewriteRule{pattern: pattern, replacement: parts[1]})
+
+		rest = strings.TrimPrefix(rest[i:], ";")
+	}
+	return rules, nil
+}
+
+// 按 RSS 列表中的 rewrite 选项改写文章链接，规则无效时记录日志并保持原链接
+func rewriteArticleLinks(store Storage, specs []feedSpec, articles []Article) []Article {
+	rules := map[string][]rewriteRule{}
+	for _, spec := range specs {
+		value := spec.Options["rewrite"]
+		if value == "" {
+			continue
+		}
+		parsed, err := parseRewriteRules(value)
+		if err != nil {
+			logError(store, "Rewrite rule error", "feed", spec.URL, "err", err)
+			continue
+		}
+		rules[spec.URL] = parsed
+	}
+	if len(rules) == 0 {
+		return articles
+	}
+
+	for i := range articles {
+		link := articles[i].Link
+		for _, rule := range rules[articles[i].feedURL] {
+			link = rule.pattern.ReplaceAllString(link, rule.replacement)
+		}
+		if link != articles[i].Link {
+			debugf("Rewrote %s -> %s", articles[i].Link, link)
+			articles[i].Link = link
+		}
+	}
+	return articles
+}
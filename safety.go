@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 被隔离的文章，记录在 quarantine.json 中，不会被发布
+type quarantineEntry struct {
+	Link    string `json:"link"`
+	Title   string `json:"title"`
+	Name    string `json:"name"`
+	FeedURL string `json:"feedUrl"`
+	// 判定来源：safebrowsing、urlhaus、burst
+	Provider string `json:"provider"`
+	// 威胁类型
+	Threat string `json:"threat"`
+	// 隔离时间，RFC3339
+	Date string `json:"date"`
+}
+
+// 链接检查服务，返回被判定为不安全的链接及其威胁类型
+type safetyProvider func(config Config, links []string) (map[string]string, error)
+
+var safetyProviders = map[string]safetyProvider{
+	"safebrowsing": checkSafeBrowsing,
+	"urlhaus":      checkURLhaus,
+}
+
+// Google Safe Browsing v4 Lookup API，每次最多 500 个链接
+func checkSafeBrowsing(config Config, links []string) (map[string]string, error) {
+	if config.SafeBrowsingKey == "" {
+		return nil, fmt.Errorf("SAFE_BROWSING_API_KEY is not set")
+	}
+
+	flagged := map[string]string{}
+	for start := 0; start < len(links); start += 500 {
+		end := start + 500
+		if end > len(links) {
+			end = len(links)
+		}
+
+		var entries []map[string]string
+		for _, link := range links[start:end] {
+			entries = append(entries, map[string]string{"url": link})
+		}
+		payload, err := json.Marshal(map[string]interface{}{
+			"client": map[string]string{"clientId": "grab-latest-rss", "clientVersion": "1.0"},
+			"threatInfo": map[string]interface{}{
+				"threatTypes":      []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"},
+				"platformTypes":    []string{"ANY_PLATFORM"},
+				"threatEntryTypes": []string{"URL"},
+				"threatEntries":    entries,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := httpClient.Post("https://safebrowsing.googleapis.com/v4/threatMatches:find?key="+url.QueryEscape(config.SafeBrowsingKey), "application/json", bytes.NewReader(payload))
+		if err != nil {
+			// url.Error 中的地址包含 API key，只保留底层错误
+			if urlErr, ok := err.(*url.Error); ok {
+				err = urlErr.Err
+			}
+			return nil, fmt.Errorf("error querying Safe Browsing: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error querying Safe Browsing: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+
+		var result struct {
+			Matches []struct {
+				ThreatType string `json:"threatType"`
+				Threat     struct {
+					URL string `json:"url"`
+				} `json:"threat"`
+			} `json:"matches"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("error parsing Safe Browsing response: %v", err)
+		}
+		for _, match := range result.Matches {
+			flagged[match.Threat.URL] = strings.ToLower(match.ThreatType)
+		}
+	}
+	return flagged, nil
+}
+
+// abuse.ch URLhaus，逐个查询链接
+func checkURLhaus(config Config, links []string) (map[string]string, error) {
+	flagged := map[string]string{}
+	for _, link := range links {
+		req, err := http.NewRequest(http.MethodPost, "https://urlhaus-api.abuse.ch/v1/url/", strings.NewReader(url.Values{"url": {link}}.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if config.URLhausKey != "" {
+			req.Header.Set("Auth-Key", config.URLhausKey)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error querying URLhaus: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error querying URLhaus: %s", resp.Status)
+		}
+
+		var result struct {
+			QueryStatus string `json:"query_status"`
+			Threat      string `json:"threat"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("error parsing URLhaus response: %v", err)
+		}
+		// ok 表示链接在 URLhaus 的恶意链接库中
+		if result.QueryStatus == "ok" {
+			flagged[link] = result.Threat
+		}
+	}
+	return flagged, nil
+}
+
+// 读取 quarantine.json
+func loadQuarantine(store Storage) ([]quarantineEntry, error) {
+	data, err := store.ReadFile("quarantine.json")
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var entries []quarantineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing quarantine.json: %v", err)
+	}
+	return entries, nil
+}
+
+// 发布前检查新文章的链接，被判定为不安全的文章隔离到 quarantine.json，不再发布。
+// 历史记录中已有的文章视为已检查过；检查服务出错时不隔离，只记录日志
+func quarantineUnsafeArticles(config Config, store Storage, articles []Article) []Article {
+	if config.SafetyCheck == "" {
+		return articles
+	}
+
+	quarantine, err := loadQuarantine(store)
+	if err != nil {
+		logError(store, "Read quarantine error", "err", err)
+		return articles
+	}
+	// 只有链接检查服务判定的文章永久隔离，其他原因（例如 burst）的记录仅供查看
+	quarantined := map[string]bool{}
+	for _, entry := range quarantine {
+		if _, ok := safetyProviders[entry.Provider]; ok {
+			quarantined[entry.Link] = true
+		}
+	}
+
+	history, err := loadHistory(store)
+	if err != nil {
+		logError(store, "Read history error", "err", err)
+	}
+	checked := map[string]bool{}
+	for _, entry := range history {
+		checked[entry.Link] = true
+	}
+
+	var links []string
+	for _, article := range articles {
+		if !quarantined[article.Link] && !checked[article.Link] {
+			links = append(links, article.Link)
+		}
+	}
+
+	// 链接 -> 判定来源和威胁类型
+	flagged := map[string][2]string{}
+	if len(links) > 0 {
+		for _, name := range strings.Split(config.SafetyCheck, ",") {
+			name = strings.TrimSpace(name)
+			provider, ok := safetyProviders[name]
+			if !ok {
+				logError(store, "Safety check error: unknown provider", "provider", name)
+				continue
+			}
+			threats, err := provider(config, links)
+			if err != nil {
+				logError(store, "Safety check error", "err", err)
+				continue
+			}
+			for link, threat := range threats {
+				flagged[link] = [2]string{name, threat}
+			}
+		}
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	kept := make([]Article, 0, len(articles))
+	for _, article := range articles {
+		if quarantined[article.Link] {
+			continue
+		}
+		verdict, ok := flagged[article.Link]
+		if !ok {
+			kept = append(kept, article)
+			continue
+		}
+
+		quarantined[article.Link] = true
+		quarantine = append(quarantine, quarantineEntry{
+			Link:     article.Link,
+			Title:    article.Title,
+			Name:     article.Name,
+			FeedURL:  article.feedURL,
+			Provider: verdict[0],
+			Threat:   verdict[1],
+			Date:     now,
+		})
+		logWarn(store, "Article quarantined", "link", article.Link, "name", article.Name, "provider", verdict[0], "threat", verdict[1])
+	}
+
+	if len(kept) < len(articles) {
+		jsonData, err := json.MarshalIndent(quarantine, "", "  ")
+		if err == nil {
+			err = store.WriteFile("quarantine.json", jsonData)
+		}
+		if err != nil {
+			logError(store, "Write quarantine error", "err", err)
+		}
+	}
+	return kept
+}
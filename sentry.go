@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 作为 Sentry 标签的日志字段，便于按 RSS 筛选和分组，其余字段放在 extra 中
+var sentryTagKeys = map[string]bool{"feed": true, "site": true, "channel": true, "provider": true, "enricher": true}
+
+// Sentry 事件，只包含用到的字段
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	Platform    string                 `json:"platform"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Message     string                 `json:"message"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	// 按消息和 RSS 分组，同一个 RSS 的同一种错误合并为一个问题
+	Fingerprint []string `json:"fingerprint,omitempty"`
+}
+
+// 上报错误的客户端：SENTRY_DSN 对应的 Sentry 项目，或 ERROR_REPORT_URL 指定的通用地址（POST 同样的 JSON）
+type errorReporter struct {
+	endpoint string
+	// Sentry 的 X-Sentry-Auth 请求头，通用地址为空
+	auth        string
+	environment string
+	level       slog.Level
+
+	mu      sync.Mutex
+	pending []sentryEvent
+}
+
+// 当前的错误上报客户端，未配置时为 nil
+var reporter *errorReporter
+
+// 根据配置创建错误上报客户端，SENTRY_DSN 优先
+func newErrorReporter(config Config) (*errorReporter, error) {
+	level, enabled, err := parseLogLevel(config.ErrorReportLevel)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+	r := &errorReporter{environment: config.SentryEnvironment, level: level}
+
+	switch {
+	case config.SentryDSN != "":
+		dsn, err := url.Parse(config.SentryDSN)
+		if err != nil || dsn.User == nil || dsn.Host == "" {
+			return nil, fmt.Errorf("invalid SENTRY_DSN")
+		}
+		// https://<key>@<host>/<path>/<project> 对应 https://<host>/<path>/api/<project>/store/
+		path := strings.TrimSuffix(dsn.Path, "/")
+		i := strings.LastIndex(path, "/")
+		if i < 0 || path[i+1:] == "" {
+			return nil, fmt.Errorf("invalid SENTRY_DSN: missing project ID")
+		}
+		r.endpoint = fmt.Sprintf("%s://%s%s/api/%s/store/", dsn.Scheme, dsn.Host, path[:i], path[i+1:])
+		r.auth = fmt.Sprintf("Sentry sentry_version=7, sentry_client=grab-latest-rss/1.0, sentry_key=%s", dsn.User.Username())
+	case config.ErrorReportURL != "":
+		r.endpoint = config.ErrorReportURL
+	default:
+		return nil, nil
+	}
+	return r, nil
+}
+
+// 暂存一个事件，由 flush 统一发送，避免在并发抓取中同步发起请求
+func (r *errorReporter) capture(event sentryEvent) {
+	event.EventID = newEventID()
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	event.Logger = "grab"
+	event.Platform = "go"
+	event.Environment = r.environment
+	event.ServerName, _ = os.Hostname()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, event)
+}
+
+// 发送暂存的事件，失败时只输出到标准输出，不再写入日志以免循环
+func (r *errorReporter) flush() {
+	r.mu.Lock()
+	events := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+
+	for _, event := range events {
+		if err := r.send(event); err != nil {
+			fmt.Printf("error reporting to %s: %v\n", r.endpointHost(), err)
+		}
+	}
+}
+
+func (r *errorReporter) send(event sentryEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Grab-latest-RSS")
+	if r.auth != "" {
+		req.Header.Set("X-Sentry-Auth", r.auth)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// 错误信息中只显示主机名，不泄露 DSN
+func (r *errorReporter) endpointHost() string {
+	if u, err := url.Parse(r.endpoint); err == nil {
+		return u.Host
+	}
+	return "error reporter"
+}
+
+// 32 位十六进制的事件 ID
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// 将日志转为事件的 handler，作为日志输出之一，发送给当前的 reporter
+type reporterHandler struct {
+	attrs []slog.Attr
+}
+
+func (h *reporterHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return reporter != nil && level >= reporter.level
+}
+
+func (h *reporterHandler) Handle(_ context.Context, record slog.Record) error {
+	event := sentryEvent{
+		Level:   strings.ToLower(record.Level.String()),
+		Message: record.Message,
+		Tags:    map[string]string{},
+		Extra:   map[string]interface{}{},
+	}
+	if event.Level == "warn" {
+		event.Level = "warning"
+	}
+
+	add := func(a slog.Attr) bool {
+		value := a.Value.Resolve()
+		if sentryTagKeys[a.Key] {
+			event.Tags[a.Key] = value.String()
+		} else {
+			event.Extra[a.Key] = value.String()
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	record.Attrs(add)
+
+	event.Fingerprint = []string{record.Message}
+	if feed := event.Tags["feed"]; feed != "" {
+		event.Fingerprint = append(event.Fingerprint, feed)
+	}
+	reporter.capture(event)
+	return nil
+}
+
+func (h *reporterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &reporterHandler{attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h *reporterHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// 发生 panic 时上报并等待发送完成，然后继续 panic，用法：defer reportPanic()
+func reportPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if reporter != nil {
+		reporter.capture(sentryEvent{
+			Level:   "fatal",
+			Message: fmt.Sprintf("panic: %v", r),
+			Extra:   map[string]interface{}{"stack": string(debug.Stack())},
+		})
+		reporter.flush()
+	}
+	panic(r)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTP 服务，在内存中保存最新的文章数据并通过 /api/rss 提供
+type apiServer struct {
+	config Config
+
+	mu       sync.RWMutex
+	data     []byte
+	etag     string
+	modified time.Time
+}
+
+func newAPIServer(config Config) *apiServer {
+	return &apiServer{config: config}
+}
+
+// 更新内存中的文章数据
+func (s *apiServer) update(articles []Article) error {
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(jsonData)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = jsonData
+	s.etag = `"` + hex.EncodeToString(sum[:8]) + `"`
+	s.modified = time.Now()
+	return nil
+}
+
+// 路由，按来源 IP 限流
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/rss", s.handleRSS)
+	mux.HandleFunc("/metrics", handleMetrics)
+	return limitRequests(mux, s.config.ServeRateLimit, s.config.ServeRateBurst)
+}
+
+// 返回最新的文章数据
+func (s *apiServer) handleRSS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", s.config.ServeCORSOrigin)
+	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "If-None-Match, If-Modified-Since")
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodGet, http.MethodHead:
+	default:
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.RLock()
+	data, etag, modified := s.data, s.etag, s.modified
+	s.mu.RUnlock()
+
+	// 首次抓取尚未完成
+	if data == nil {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "articles not ready yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.config.ServeMaxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+
+	// 由 ServeContent 处理 If-None-Match / If-Modified-Since
+	http.ServeContent(w, r, "rss_data.json", modified, bytes.NewReader(data))
+}
+
+// 启动 HTTP 服务，ctx 结束时优雅关闭
+func (s *apiServer) start(ctx context.Context) {
+	fmt.Printf("Serving articles at http://%s/api/rss\n", s.config.ServeAddr)
+	startHTTPServer(ctx, s.config.ServeAddr, s.handler())
+}
+
+// 在后台启动 HTTP 服务，ctx 结束时优雅关闭
+func startHTTPServer(ctx context.Context, addr string, handler http.Handler) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		MaxHeaderBytes:    serveMaxHeaderBytes,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("error running HTTP server on %s: %v\n", addr, err)
+		}
+	}()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 请求头大小上限
+const serveMaxHeaderBytes = 16 << 10
+
+// 请求体大小上限，API 只接受 GET，正常请求没有请求体
+const serveMaxBodyBytes = 1 << 10
+
+// 按来源 IP 的令牌桶限流：每个 IP 最多连续请求 burst 次，之后每分钟补充 perMinute 次
+type ipRateLimiter struct {
+	perMinute int
+	burst     int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	// 上一次清理空闲令牌桶的时间
+	swept time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newIPRateLimiter(perMinute int, burst int) *ipRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &ipRateLimiter{perMinute: perMinute, burst: burst, buckets: map[string]*tokenBucket{}, swept: time.Now()}
+}
+
+// 消耗一个令牌，没有令牌时返回 false 和需要等待的时间
+func (l *ipRateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := float64(l.perMinute) / 60
+	// 补满后的令牌桶与新建的相同，定期删除以免占用内存
+	if now.Sub(l.swept) > time.Minute {
+		for key, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*rate >= float64(l.burst) {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+
+	b := l.buckets[ip]
+	if b == nil {
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[ip] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// 限制请求频率和请求体大小，perMinute 为 0 时只限制大小。
+// 来源 IP 取自连接地址，经过反向代理时所有请求共用代理的 IP
+func limitRequests(handler http.Handler, perMinute int, burst int) http.Handler {
+	var limiter *ipRateLimiter
+	if perMinute > 0 {
+		limiter = newIPRateLimiter(perMinute, burst)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > serveMaxBodyBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, serveMaxBodyBytes)
+
+		if limiter != nil {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+			if ok, wait := limiter.allow(ip, time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(wait/time.Second)+1))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 需要迁移的本地状态目录，key 为压缩包内的目录名
+func stateDirs(config Config) map[string]string {
+	dirs := map[string]string{}
+	if config.CacheDir != "off" {
+		dirs["cache"] = config.CacheDir
+	}
+	return dirs
+}
+
+// 需要迁移的数据目录文件，通过存储后端读写，在压缩包内位于 data/ 下。
+// history.json 中记录了已经见过的文章，缺少它时迁移后的第一次运行会重新建立基线
+var stateFiles = []string{"history.json", "stats.json", "feed_health.json", "outbox.json"}
+
+// 压缩包内数据目录文件所在的目录名
+const stateDataDir = "data"
+
+// 处理 state 子命令：state export <file> / state import <file>
+func runStateCommand(config Config, store Storage, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: state export|import <file.tar.gz>")
+	}
+
+	switch args[0] {
+	case "export":
+		return exportState(config, store, args[1])
+	case "import":
+		return importState(config, store, args[1])
+	default:
+		return fmt.Errorf("unknown state command: %s", args[0])
+	}
+}
+
+// 将本地状态和数据目录中的状态文件打包为 tar.gz
+func exportState(config Config, store Storage, fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", fileName, err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	for name, dir := range stateDirs(config) {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// 状态目录尚未创建，跳过
+				if os.IsNotExist(err) && path == dir {
+					return nil
+				}
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = name + "/" + filepath.ToSlash(rel)
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error exporting %s: %v", name, err)
+		}
+	}
+
+	now := time.Now()
+	for _, name := range stateFiles {
+		data, err := store.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("error exporting %s: %v", name, err)
+		}
+		// 尚未生成的文件不打包
+		if data == nil {
+			continue
+		}
+		header := &tar.Header{Name: stateDataDir + "/" + name, Mode: 0o644, Size: int64(len(data)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// 从 tar.gz 恢复本地状态，覆盖同名文件，数据目录中的状态文件写回存储后端
+func importState(config Config, store Storage, fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", fileName, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", fileName, err)
+	}
+	defer gz.Close()
+
+	dirs := stateDirs(config)
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", fileName, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, rel, _ := strings.Cut(header.Name, "/")
+		if name == stateDataDir {
+			if !isStateFile(rel) {
+				fmt.Printf("skipping unknown state entry: %s\n", header.Name)
+				continue
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %v", fileName, err)
+			}
+			if err := store.WriteFile(rel, data); err != nil {
+				return fmt.Errorf("error importing %s: %v", rel, err)
+			}
+			continue
+		}
+		dir, ok := dirs[name]
+		if !ok {
+			fmt.Printf("skipping unknown state entry: %s\n", header.Name)
+			continue
+		}
+
+		// 防止压缩包中的路径跳出状态目录
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in state bundle: %s", header.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return err
+		}
+		os.Chtimes(target, header.ModTime, header.ModTime)
+	}
+
+	return nil
+}
+
+// 是否为需要迁移的数据目录文件
+func isStateFile(name string) bool {
+	for _, file := range stateFiles {
+		if file == name {
+			return true
+		}
+	}
+	return false
+}
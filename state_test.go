@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	oldDir := t.TempDir()
+	oldConfig := Config{OutputDir: filepath.Join(oldDir, "public"), CacheDir: filepath.Join(oldDir, "cache")}
+	oldStore, err := newLocalStorage(oldConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"history.json":     `[{"id":"be75076c0bddd36b","guid":"tag:g-1"}]`,
+		"stats.json":       `{"runs":3}`,
+		"feed_health.json": `{"https://example.com/feed":{"failures":1}}`,
+	}
+	for name, content := range files {
+		if err := oldStore.WriteFile(name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 不需要迁移的文件不打包
+	if err := oldStore.WriteFile("rss_data.json", []byte("[]")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(oldConfig.CacheDir, "feeds"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(oldConfig.CacheDir, "feeds", "entry.json"), []byte(`{"key":"k"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := filepath.Join(oldDir, "state.tar.gz")
+	if err := runStateCommand(oldConfig, oldStore, []string{"export", bundle}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	newDir := t.TempDir()
+	newConfig := Config{OutputDir: filepath.Join(newDir, "public"), CacheDir: filepath.Join(newDir, "cache")}
+	newStore, err := newLocalStorage(newConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runStateCommand(newConfig, newStore, []string{"import", bundle}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	for name, content := range files {
+		data, err := newStore.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+	if data, _ := newStore.ReadFile("outbox.json"); data != nil {
+		t.Errorf("outbox.json was not exported but was imported: %q", data)
+	}
+	if data, _ := newStore.ReadFile("rss_data.json"); data != nil {
+		t.Errorf("rss_data.json should not be part of the state bundle: %q", data)
+	}
+	data, err := os.ReadFile(filepath.Join(newConfig.CacheDir, "feeds", "entry.json"))
+	if err != nil || string(data) != `{"key":"k"}` {
+		t.Errorf("cache entry = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 单个 RSS 在本次运行中的请求和流量
+type feedStats struct {
+	URL         string `json:"url"`
+	Requests    int    `json:"requests"`
+	Bytes       int64  `json:"bytes"`
+	NotModified bool   `json:"notModified,omitempty"`
+	DurationMs  int64  `json:"durationMs"`
+	Error       string `json:"error,omitempty"`
+	ErrorKind   string `json:"errorKind,omitempty"`
+}
+
+// 本次运行的统计，写入 stats.json
+type runStats struct {
+	// 开始和结束时间，RFC3339
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt"`
+	// 抓取的 RSS 数量
+	Feeds int `json:"feeds"`
+	// 失败的 RSS 数量
+	Failed int `json:"failed"`
+	// 返回 304 的 RSS 数量
+	NotModified int `json:"notModified"`
+	// 请求总数
+	Requests int `json:"requests"`
+	// 下载总字节数
+	Bytes int64 `json:"bytes"`
+	// 各 RSS 的明细，流量大的在前
+	PerFeed []feedStats `json:"perFeed"`
+}
+
+// 汇总本次运行的请求和流量，写入 stats.json
+func writeRunStats(store Storage, start time.Time, results []feedResult) error {
+	stats := runStats{
+		StartedAt:  start.Format(time.RFC3339),
+		FinishedAt: time.Now().Format(time.RFC3339),
+		Feeds:      len(results),
+		PerFeed:    make([]feedStats, 0, len(results)),
+	}
+
+	for _, result := range results {
+		fs := feedStats{
+			URL:         result.URL,
+			Requests:    result.Requests,
+			Bytes:       result.Bytes,
+			NotModified: result.NotModified,
+			DurationMs:  result.Duration.Milliseconds(),
+		}
+		if result.Err != nil {
+			fs.Error = result.Err.Error()
+			fs.ErrorKind = result.ErrKind
+			stats.Failed++
+		}
+		if result.NotModified {
+			stats.NotModified++
+		}
+		stats.Requests += result.Requests
+		stats.Bytes += result.Bytes
+		stats.PerFeed = append(stats.PerFeed, fs)
+	}
+
+	sort.SliceStable(stats.PerFeed, func(i, j int) bool {
+		return stats.PerFeed[i].Bytes > stats.PerFeed[j].Bytes
+	})
+
+	jsonData, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile("stats.json", jsonData)
+}
+
+// run.log 保留的运行记录条数
+const runLogMaxLines = 1000
+
+// 本次运行的摘要，追加一行到 run.log，例如：
+//
+//	[Mon Jan 2 15:04:2006] status=ok feeds=120 ok=118 failed=2 new=3 published=118 duration=41s
+//
+// 常驻模式下 tier 为本次抓取的分组。发布失败时 status=error 并附上错误
+func appendRunLog(store Storage, tier string, start time.Time, results []feedResult, published int, fresh int, runErr error) error {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]", localTime().Format("Mon Jan 2 15:04:2006"))
+	if runErr != nil {
+		b.WriteString(" status=error")
+	} else {
+		b.WriteString(" status=ok")
+	}
+	if tier != "" {
+		fmt.Fprintf(&b, " tier=%s", tier)
+	}
+	fmt.Fprintf(&b, " feeds=%d ok=%d failed=%d new=%d published=%d duration=%v",
+		len(results), len(results)-failed, failed, fresh, published, time.Since(start).Round(time.Second))
+	if budgeted, ok := store.(budgetedStorage); ok {
+		if fields := budgeted.budgetFields(); fields != "" {
+			b.WriteString(" " + fields)
+		}
+	}
+	if runErr != nil {
+		fmt.Fprintf(&b, " error=%q", runErr.Error())
+	}
+
+	data, err := store.ReadFile("run.log")
+	if err != nil {
+		return err
+	}
+	var lines []string
+	if text := strings.TrimRight(string(data), "\n"); text != "" {
+		lines = strings.Split(text, "\n")
+	}
+	lines = append(lines, b.String())
+	if len(lines) > runLogMaxLines {
+		lines = lines[len(lines)-runLogMaxLines:]
+	}
+	return store.WriteFile("run.log", []byte(strings.Join(lines, "\n")+"\n"))
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// 存储后端：读取 RSS 列表、保存文章数据、追加日志
+type Storage interface {
+	// 读取 RSS 列表
+	ReadFeeds() ([]string, error)
+	// 写回 RSS 列表
+	WriteFeeds(lines []string) error
+	// 保存爬虫抓取的文章数据
+	SaveArticles(articles []Article) error
+	// 追加日志到 error.log，一次运行的日志合并为一次调用
+	AppendLog(message string) error
+	// 读取数据目录下的文件，文件不存在时返回 nil
+	ReadFile(name string) ([]byte, error)
+	// 写入数据目录下的文件，已存在时覆盖
+	WriteFile(name string, data []byte) error
+}
+
+// 有 API 调用配额的存储后端（目前只有 GitHub）
+type budgetedStorage interface {
+	// 运行开始时检查剩余配额，不足时发出警告并降级
+	checkBudget() error
+	// 本次运行的调用情况
+	budgetReport() string
+	// 写入运行摘要的 key=value 字段
+	budgetFields() string
+}
+
+// 可以把一次运行的写入合并为一次提交的存储后端（目前只有 GitHub）
+type batchedStorage interface {
+	// 开始暂存写入，之后的读取优先返回暂存的内容
+	beginBatch()
+	// 一次提交暂存的全部写入并结束暂存
+	commitBatch() error
+}
+
+// 运行开始时开始合并写入
+func beginStorageBatch(store Storage) {
+	if b, ok := store.(batchedStorage); ok {
+		b.beginBatch()
+	}
+}
+
+// 提交本次运行暂存的写入，没有暂存的写入时什么也不做
+func commitStorageBatch(store Storage) error {
+	if b, ok := store.(batchedStorage); ok {
+		return b.commitBatch()
+	}
+	return nil
+}
+
+// 运行开始时检查存储后端的 API 配额
+func checkStorageBudget(store Storage) {
+	if b, ok := store.(budgetedStorage); ok {
+		if err := b.checkBudget(); err != nil {
+			fmt.Printf("%v\n", err)
+		}
+	}
+}
+
+// 运行结束时输出存储后端的 API 调用情况
+func reportStorageBudget(store Storage) {
+	if b, ok := store.(budgetedStorage); ok {
+		if report := b.budgetReport(); report != "" {
+			fmt.Println(report)
+		}
+	}
+}
+
+// 已注册的存储后端，由各后端在 init 中注册
+var storageBackends = map[string]func(config Config) (Storage, error){}
+
+// 注册存储后端
+func registerStorage(name string, factory func(config Config) (Storage, error)) {
+	storageBackends[name] = factory
+}
+
+// 根据 STORAGE_BACKEND 创建存储后端
+func newStorage(config Config) (Storage, error) {
+	// 逗号分隔的多个后端
+	if names := strings.Split(config.StorageBackend, ","); len(names) > 1 {
+		return newMultiStorage(config, names)
+	}
+	factory, ok := storageBackends[config.StorageBackend]
+	if !ok {
+		var names []string
+		for name := range storageBackends {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown storage backend %q, available: %s", config.StorageBackend, strings.Join(names, ", "))
+	}
+	return factory(config)
+}
+
+func init() {
+	registerStorage("none", newNoneStorage)
+}
+
+// 不持久化任何数据，RSS 列表读取本地文件，日志输出到标准输出，适合仅使用 --serve 的场景
+type noneStorage struct {
+	config Config
+}
+
+func newNoneStorage(config Config) (Storage, error) {
+	return &noneStorage{config: config}, nil
+}
+
+// 从本地 rss_feeds.txt 读取 RSS
+func (s *noneStorage) ReadFeeds() ([]string, error) {
+	return readFeedsFromFile(s.config.FeedsFile)
+}
+
+// 写回本地 rss_feeds.txt
+func (s *noneStorage) WriteFeeds(lines []string) error {
+	return writeFeedsToFile(s.config.FeedsFile, lines)
+}
+
+// 丢弃文章数据
+func (s *noneStorage) SaveArticles(articles []Article) error {
+	return nil
+}
+
+// 不保存日志，日志已输出到标准输出
+func (s *noneStorage) AppendLog(message string) error {
+	return nil
+}
+
+// 不保存任何文件
+func (s *noneStorage) ReadFile(name string) ([]byte, error) {
+	return nil, nil
+}
+
+// 丢弃文件
+func (s *noneStorage) WriteFile(name string, data []byte) error {
+	return nil
+}
+
+// 根据文件扩展名推断 Content-Type
+func contentTypeOf(name string) string {
+	// 系统的 MIME 表中通常没有 .log
+	if path.Ext(name) == ".log" {
+		return "text/plain; charset=utf-8"
+	}
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// 从本地文件中读取 RSS
+func readFeedsFromFile(filePath string) ([]string, error) {
+
+	// 打开 rss_feeds.txt
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	var feeds []string
+	scanner := bufio.NewScanner(file)
+
+	// 按行读取文件内容，将每一行作为 RSS 源并添加到 feeds 列表中
+	for scanner.Scan() {
+		feeds = append(feeds, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+
+	return feeds, nil
+}
+
+// 将 RSS 列表写回本地文件
+func writeFeedsToFile(filePath string, lines []string) error {
+	content := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	registerStorage("checkout", newCheckoutStorage)
+}
+
+// 为 GitHub Actions 设计的存储：按 github 后端的仓库布局（GITHUB_DIR、GITHUB_FEEDS_FILE、GITHUB_DATA_FILE）
+// 直接读写 actions/checkout 检出的工作区，不调用 GitHub API，由后续步骤提交或部署到 Pages
+type checkoutStorage struct {
+	*localStorage
+}
+
+func newCheckoutStorage(config Config) (Storage, error) {
+	localConfig := config
+	localConfig.OutputDir = filepath.Join(config.CheckoutDir, filepath.FromSlash(strings.Trim(config.GithubDir, "/")))
+	localConfig.FeedsFile = filepath.Join(localConfig.OutputDir, filepath.FromSlash(config.GithubFeedsFile))
+	local, err := newLocalStorage(localConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &checkoutStorage{localStorage: local.(*localStorage)}, nil
+}
+
+// 数据目录中的文件名，文章数据使用 GITHUB_DATA_FILE
+func (s *checkoutStorage) fileName(name string) string {
+	if name == "rss_data.json" {
+		return s.config.GithubDataFile
+	}
+	return name
+}
+
+// 将爬虫抓取的数据保存到工作区中的 GITHUB_DATA_FILE
+func (s *checkoutStorage) SaveArticles(articles []Article) error {
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+	return s.WriteFile("rss_data.json", jsonData)
+}
+
+func (s *checkoutStorage) ReadFile(name string) ([]byte, error) {
+	return s.localStorage.ReadFile(s.fileName(name))
+}
+
+func (s *checkoutStorage) WriteFile(name string, data []byte) error {
+	return s.localStorage.WriteFile(s.fileName(name), data)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+func init() {
+	registerStorage("cos", newCOSStorage)
+}
+
+// 腾讯云 COS 存储，数据保存在存储桶的 COS_PREFIX（默认 rss/）目录下，RSS 列表读取本地文件
+type cosStorage struct {
+	config Config
+	client *cos.Client
+	// 上传后刷新 CDN 缓存，未配置时为 nil
+	purger *cdnPurger
+}
+
+func newCOSStorage(config Config) (Storage, error) {
+
+	// 解析 COS 存储桶的基础 URL
+	baseURL, err := url.Parse(config.CosBucketURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COS bucket URL: %v", err)
+	}
+	b := &cos.BaseURL{BucketURL: baseURL}
+
+	// 创建 COS 客户端，使用授权信息进行认证
+	client := cos.NewClient(b, &http.Client{
+		Transport: &cos.AuthorizationTransport{
+			SecretID:  config.SecretID,
+			SecretKey: config.SecretKey,
+		},
+		Timeout: time.Second * 30,
+	})
+
+	return &cosStorage{config: config, client: client, purger: newCDNPurger(config)}, nil
+}
+
+// 读取 RSS 列表：设置了 COS_FEEDS_KEY 时从存储桶读取（.opml 结尾时按 OPML 解析），否则读取本地 rss_feeds.txt
+func (s *cosStorage) ReadFeeds() ([]string, error) {
+	name := s.config.CosFeedsKey
+	if name == "" {
+		return readFeedsFromFile(s.config.FeedsFile)
+	}
+
+	data, err := s.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%s not found in COS bucket", s.key(name))
+	}
+	if cosFeedsOPML(name) {
+		return parseOPML(data)
+	}
+
+	var feeds []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		feeds = append(feeds, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", name, err)
+	}
+	return feeds, nil
+}
+
+// 写回 RSS 列表，OPML 格式的列表无法保存选项，不支持写回
+func (s *cosStorage) WriteFeeds(lines []string) error {
+	name := s.config.CosFeedsKey
+	if name == "" {
+		return writeFeedsToFile(s.config.FeedsFile, lines)
+	}
+	if cosFeedsOPML(name) {
+		return fmt.Errorf("cannot write the feed list back to %s, edit the OPML file directly", name)
+	}
+	return s.WriteFile(name, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// 存储桶中的 RSS 列表是否为 OPML
+func cosFeedsOPML(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".opml")
+}
+
+// 规范化 COS_PREFIX：去掉开头的 /，非空时以 / 结尾
+func cosPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// 数据文件在存储桶中的对象键
+func (s *cosStorage) key(name string) string {
+	return s.config.CosPrefix + name
+}
+
+// 上传对象，设置 Content-Type 和 COS_CACHE_CONTROL
+func (s *cosStorage) put(name string, data []byte) error {
+	_, err := s.client.Object.Put(context.Background(), s.key(name), bytes.NewReader(data), &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType:  contentTypeOf(name),
+			CacheControl: s.config.CosCacheControl,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	s.purger.uploaded(name)
+	return nil
+}
+
+// 将爬虫抓取的数据保存到 COS
+func (s *cosStorage) SaveArticles(articles []Article) error {
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+
+	if err := s.put("rss_data.json", jsonData); err != nil {
+		return fmt.Errorf("error saving data to COS: %v", err)
+	}
+
+	return nil
+}
+
+// 读取存储桶数据目录下的文件
+func (s *cosStorage) ReadFile(name string) ([]byte, error) {
+	resp, err := s.client.Object.Get(context.Background(), s.key(name), nil)
+	if err != nil {
+		if errResp, ok := err.(*cos.ErrorResponse); ok && errResp.Code == "NoSuchKey" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error downloading %s from COS: %v", name, err)
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// 写入存储桶数据目录下的文件
+func (s *cosStorage) WriteFile(name string, data []byte) error {
+	if err := s.put(name, data); err != nil {
+		return fmt.Errorf("error saving %s to COS: %v", name, err)
+	}
+	return nil
+}
+
+// 运行开始时开始记录需要刷新 CDN 缓存的文件
+func (s *cosStorage) beginBatch() {
+	if s.purger != nil {
+		s.purger.start()
+	}
+}
+
+// 运行结束时刷新 CDN 缓存，刷新失败只记录警告，不影响已上传的数据
+func (s *cosStorage) commitBatch() error {
+	if s.purger != nil {
+		s.purger.finish()
+	}
+	return nil
+}
+
+// 追加日志到 COS 的 error.log 文件
+func (s *cosStorage) AppendLog(message string) error {
+
+	// 尝试获取 error.log 文件
+	var existingLog []byte
+	resp, err := s.client.Object.Get(context.Background(), s.key("error.log"), nil)
+	if err != nil {
+		if errResp, ok := err.(*cos.ErrorResponse); !ok || errResp.Code != "NoSuchKey" {
+			return fmt.Errorf("error downloading error.log from COS: %v", err)
+		}
+	} else {
+
+		// 如果文件存在，读取文件内容
+		defer resp.Body.Close()
+		existingLog, _ = io.ReadAll(resp.Body)
+	}
+
+	// 将新的错误信息追加到现有的日志内容中，超过上限时轮转
+	newLog, err := appendErrorLog(s.config, s, existingLog, message)
+	if err != nil {
+		return err
+	}
+
+	// 上传更新后的 error.log 文件
+	if err := s.put("error.log", newLog); err != nil {
+		return fmt.Errorf("error saving error log to COS: %v", err)
+	}
+
+	return nil
+}
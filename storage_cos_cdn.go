@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 腾讯云 CDN API 的地址，可以替换以便测试
+var cdnAPIEndpoint = "https://cdn.tencentcloudapi.com"
+
+// 腾讯云 CDN API 的请求客户端
+var cdnHTTPClient = &http.Client{Timeout: time.Second * 30}
+
+// PurgeUrlsCache 单次最多提交的 URL 数量
+const cdnPurgeBatchSize = 1000
+
+// 上传到 COS 后刷新腾讯云 CDN 的缓存，读者不用等缓存过期才能看到新数据。
+// 运行中写入的文件先记下，运行结束时一次提交；不在运行中（例如 feeds 命令）时上传后立即刷新
+type cdnPurger struct {
+	// CDN 上 COS_PREFIX 目录的地址，以 / 结尾
+	base      string
+	secretID  string
+	secretKey string
+	// 需要刷新的文件，path.Match 格式
+	patterns []string
+
+	mu     sync.Mutex
+	active bool
+	urls   []string
+}
+
+// 根据配置创建 CDN 刷新，没有设置 COS_CDN_URL 时返回 nil
+func newCDNPurger(config Config) *cdnPurger {
+	if config.CDNPurgeURL == "" {
+		return nil
+	}
+	p := &cdnPurger{
+		base:      strings.TrimSuffix(config.CDNPurgeURL, "/") + "/",
+		secretID:  config.CDNSecretID,
+		secretKey: config.CDNSecretKey,
+	}
+	if p.secretID == "" {
+		p.secretID, p.secretKey = config.SecretID, config.SecretKey
+	}
+	for _, pattern := range strings.Split(config.CDNPurgeFiles, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			p.patterns = append(p.patterns, pattern)
+		}
+	}
+	return p
+}
+
+// 文件是否需要刷新
+func (p *cdnPurger) matches(name string) bool {
+	for _, pattern := range p.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 开始记录需要刷新的文件
+func (p *cdnPurger) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active = true
+	p.urls = nil
+}
+
+// 上传成功后调用，运行中只记下地址
+func (p *cdnPurger) uploaded(name string) {
+	if p == nil || !p.matches(name) {
+		return
+	}
+	url := p.base + name
+
+	p.mu.Lock()
+	if p.active {
+		for _, u := range p.urls {
+			if u == url {
+				p.mu.Unlock()
+				return
+			}
+		}
+		p.urls = append(p.urls, url)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+
+	if err := p.purge([]string{url}); err != nil {
+		logWarn(nil, "CDN purge error", "url", url, "err", err)
+	}
+}
+
+// 结束记录，刷新记下的全部地址
+func (p *cdnPurger) finish() {
+	p.mu.Lock()
+	urls := p.urls
+	p.active = false
+	p.urls = nil
+	p.mu.Unlock()
+
+	for len(urls) > 0 {
+		n := min(len(urls), cdnPurgeBatchSize)
+		if err := p.purge(urls[:n]); err != nil {
+			logWarn(nil, "CDN purge error", "urls", n, "err", err)
+		}
+		urls = urls[n:]
+	}
+}
+
+// 调用 PurgeUrlsCache 刷新 URL 缓存
+func (p *cdnPurger) purge(urls []string) error {
+	payload, err := json.Marshal(map[string]interface{}{"Urls": urls})
+	if err != nil {
+		return err
+	}
+	req, err := newTencentCloudRequest(cdnAPIEndpoint, "cdn", "PurgeUrlsCache", "2018-06-06", payload, p.secretID, p.secretKey)
+	if err != nil {
+		return err
+	}
+	resp, err := cdnHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Response struct {
+			TaskId string
+			Error  *struct {
+				Code    string
+				Message string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("unexpected response %s: %v", resp.Status, err)
+	}
+	if e := result.Response.Error; e != nil {
+		return fmt.Errorf("%s: %s", e.Code, e.Message)
+	}
+	debugf("Purged %d CDN URLs, task %s", len(urls), result.Response.TaskId)
+	return nil
+}
+
+// 构造使用 TC3-HMAC-SHA256 签名的腾讯云 API 3.0 请求
+func newTencentCloudRequest(endpoint string, service string, action string, version string, payload []byte, secretID string, secretKey string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	timestamp := fmt.Sprintf("%d", now.Unix())
+	date := now.Format("2006-01-02")
+	contentType := "application/json; charset=utf-8"
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost,
+		"/",
+		"",
+		"content-type:" + contentType + "\nhost:" + req.URL.Host + "\n",
+		"content-type;host",
+		sha256Hex(payload),
+	}, "\n")
+	scope := date + "/" + service + "/tc3_request"
+	stringToSign := "TC3-HMAC-SHA256\n" + timestamp + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSHA256(hmacSHA256(hmacSHA256([]byte("TC3"+secretKey), date), service), "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Version", version)
+	req.Header.Set("X-TC-Timestamp", timestamp)
+	req.Header.Set("Authorization", fmt.Sprintf("TC3-HMAC-SHA256 Credential=%s/%s, SignedHeaders=content-type;host, Signature=%s", secretID, scope, signature))
+	return req, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v39/github"
+	"golang.org/x/oauth2"
+)
+
+func init() {
+	registerStorage("gist", newGistStorage)
+}
+
+// 通过 Gists API 把数据保存在一个 GitHub Gist 中，不向网站仓库提交任何内容。
+// Gist 不支持目录，archive/、badges/ 等子目录中的文件以 __ 代替 / 保存
+type gistFiles struct {
+	client *github.Client
+
+	mu sync.Mutex
+	// Gist ID，为空时在第一次写入时创建
+	id string
+}
+
+func newGistStorage(config Config) (Storage, error) {
+	token := config.GistToken
+	if token == "" {
+		token = config.GithubToken
+	}
+	if token == "" {
+		return nil, fmt.Errorf("GIST_TOKEN or TOKEN is required for the gist backend")
+	}
+
+	client := github.NewClient(oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: token,
+	})))
+	return &remoteStorage{config: config, kind: "Gist", files: &gistFiles{client: client, id: config.GistID}}, nil
+}
+
+// 数据文件在 Gist 中的文件名
+func gistFileName(name string) github.GistFilename {
+	return github.GistFilename(strings.ReplaceAll(name, "/", "__"))
+}
+
+func (f *gistFiles) gistID() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.id
+}
+
+func (f *gistFiles) get(name string) ([]byte, error) {
+	id := f.gistID()
+	if id == "" {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	gist, _, err := f.client.Gists.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := gist.Files[gistFileName(name)]
+	if !ok {
+		return nil, nil
+	}
+
+	// 超过 1MB 的文件内容会被截断，从 raw_url 下载完整内容
+	content := file.GetContent()
+	if len(content) >= file.GetSize() {
+		return []byte(content), nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.GetRawURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s downloading %s", resp.Status, file.GetRawURL())
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (f *gistFiles) put(name string, data []byte) error {
+	// Gist 只能保存文本
+	if !utf8.Valid(data) {
+		return fmt.Errorf("gist files must be UTF-8 text")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ctx := context.Background()
+	files := map[github.GistFilename]github.GistFile{
+		gistFileName(name): {Content: github.String(string(data))},
+	}
+
+	if f.id == "" {
+		gist, _, err := f.client.Gists.Create(ctx, &github.Gist{
+			Description: github.String("Grab-latest-RSS data"),
+			Public:      github.Bool(false),
+			Files:       files,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating gist: %v", err)
+		}
+		f.id = gist.GetID()
+		fmt.Printf("Created gist %s, set GIST_ID=%s to keep using it\n", gist.GetHTMLURL(), f.id)
+		return nil
+	}
+
+	_, _, err := f.client.Gists.Edit(ctx, f.id, &github.Gist{Files: files})
+	return err
+}
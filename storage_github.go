@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"golang.org/x/oauth2"
+)
+
+func init() {
+	registerStorage("github", newGithubStorage)
+}
+
+// GitHub 仓库存储，数据保存在仓库 GITHUB_BRANCH 分支的 GITHUB_DIR 目录下（默认 master 分支的 api/）
+type githubStorage struct {
+	config Config
+	client *github.Client
+	budget *githubBudget
+	// 开启 GITHUB_SINGLE_COMMIT 时暂存一次运行的写入
+	batch *githubBatch
+}
+
+func newGithubStorage(config Config) (Storage, error) {
+	if config.GithubAuthorName != "" && config.GithubAuthorEmail == "" && config.GithubAuthorName != "github-actions[bot]" {
+		return nil, fmt.Errorf("GITHUB_AUTHOR_EMAIL is required when GITHUB_AUTHOR_NAME is set")
+	}
+
+	// 使用 OAuth2 进行验证
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{
+		AccessToken: config.GithubToken,
+	}))
+	// 遇到限流时等待后重试
+	budget := &githubBudget{}
+	httpClient.Transport = &githubRateLimitTransport{base: httpClient.Transport, maxWait: config.GithubRateLimitWait, budget: budget}
+	client := github.NewClient(httpClient)
+
+	return &githubStorage{config: config, client: client, budget: budget, batch: &githubBatch{}}, nil
+}
+
+// 数据文件在仓库中的路径，RSS 列表和文章数据使用 GITHUB_FEEDS_FILE、GITHUB_DATA_FILE 指定的文件名
+func (s *githubStorage) repoPath(name string) string {
+	switch name {
+	case "rss_feeds.txt":
+		name = s.config.GithubFeedsFile
+	case "rss_data.json":
+		name = s.config.GithubDataFile
+	}
+	if dir := strings.Trim(s.config.GithubDir, "/"); dir != "" {
+		return dir + "/" + name
+	}
+	return name
+}
+
+// 提交的作者和提交者，未设置 GITHUB_AUTHOR_NAME 时返回 nil，由 GitHub 使用 TOKEN 对应的账号
+func (s *githubStorage) commitAuthor() *github.CommitAuthor {
+	if s.config.GithubAuthorName == "" {
+		return nil
+	}
+	email := s.config.GithubAuthorEmail
+	if email == "" && s.config.GithubAuthorName == "github-actions[bot]" {
+		email = "41898282+github-actions[bot]@users.noreply.github.com"
+	}
+	return &github.CommitAuthor{Name: github.String(s.config.GithubAuthorName), Email: github.String(email)}
+}
+
+// 获取仓库 GITHUB_BRANCH 分支中的文件，文件不存在时返回 nil
+func (s *githubStorage) getFile(ctx context.Context, filePath string) (*github.RepositoryContent, error) {
+	file, _, resp, err := s.client.Repositories.GetContents(ctx, s.config.GithubName, s.config.GithubRepository, filePath, &github.RepositoryContentGetOptions{
+		Ref: s.config.GithubBranch,
+	})
+	s.budget.observe(resp)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return file, err
+}
+
+// 创建或更新仓库中的文件
+func (s *githubStorage) putFile(ctx context.Context, file *github.RepositoryContent, filePath string, fileName string, content []byte) error {
+	return s.putFileOnBranch(ctx, s.config.GithubBranch, file, filePath, fileName, content)
+}
+
+// 在指定分支上创建或更新文件
+func (s *githubStorage) putFileOnBranch(ctx context.Context, branch string, file *github.RepositoryContent, filePath string, fileName string, content []byte) error {
+
+	// 较大的文件和非 UTF-8 内容通过 Git Data API 提交
+	if needsGitDataAPI(content) {
+		message := "Update " + fileName
+		if file == nil {
+			message = "Create " + fileName
+		}
+		if err := s.commitFile(ctx, branch, filePath, message, content); err != nil {
+			return fmt.Errorf("error saving %s to GitHub: %v", fileName, err)
+		}
+		return nil
+	}
+
+	// 文件不存在，创建新文件
+	if file == nil {
+		_, resp, err := s.client.Repositories.CreateFile(ctx, s.config.GithubName, s.config.GithubRepository, filePath, &github.RepositoryContentFileOptions{
+			// 提交信息
+			Message: github.String(attributeCommit("Create " + fileName)),
+			// 数据
+			Content: content,
+			// 分支
+			Branch: github.String(branch),
+			// 作者和提交者
+			Author:    s.commitAuthor(),
+			Committer: s.commitAuthor(),
+		})
+		s.budget.observe(resp)
+		if err != nil {
+			return fmt.Errorf("error creating %s in GitHub: %v", fileName, err)
+		}
+		return nil
+	}
+
+	_, resp, err := s.client.Repositories.UpdateFile(ctx, s.config.GithubName, s.config.GithubRepository, filePath, &github.RepositoryContentFileOptions{
+		Message:   github.String(attributeCommit("Update " + fileName)),
+		Content:   content,
+		SHA:       github.String(*file.SHA),
+		Branch:    github.String(branch),
+		Author:    s.commitAuthor(),
+		Committer: s.commitAuthor(),
+	})
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error updating %s in GitHub: %v", fileName, err)
+	}
+	return nil
+}
+
+// 从 GitHub 仓库中获取 RSS 文件
+func (s *githubStorage) ReadFeeds() ([]string, error) {
+	ctx := context.Background()
+
+	filePath := s.repoPath("rss_feeds.txt")
+	content, ok := s.batch.get(filePath)
+	if !ok {
+		file, err := s.getFile(ctx, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %s from GitHub: %v", filePath, err)
+		}
+		if file == nil {
+			return nil, fmt.Errorf("%s not found in GitHub repository", filePath)
+		}
+
+		// 获取文件内容
+		content, err = s.fileContent(ctx, file)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s content: %v", filePath, err)
+		}
+	}
+
+	var feeds []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	// 按行读取文件内容，将每一行作为 RSS 并添加到 feeds 列表中
+	for scanner.Scan() {
+		feeds = append(feeds, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading RSS file content: %v", err)
+	}
+
+	return feeds, nil
+}
+
+// 写回 GitHub 仓库中的 RSS 文件，开启 FEEDS_PULL_REQUEST 时改为提交 Pull Request
+func (s *githubStorage) WriteFeeds(lines []string) error {
+	if s.config.FeedsPullRequest {
+		return s.proposeFeeds(lines)
+	}
+	return s.WriteFile("rss_feeds.txt", []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// 将爬虫抓取的数据保存到 GitHub
+func (s *githubStorage) SaveArticles(articles []Article) error {
+
+	// 将文章数据序列化为 JSON 格式
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+
+	return s.WriteFile("rss_data.json", jsonData)
+}
+
+// 读取仓库数据目录下的文件，优先读取本次运行暂存的写入
+func (s *githubStorage) ReadFile(name string) ([]byte, error) {
+	ctx := context.Background()
+	if data, ok := s.batch.get(s.repoPath(name)); ok {
+		return data, nil
+	}
+	file, err := s.getFile(ctx, s.repoPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s from GitHub: %v", name, err)
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	content, err := s.fileContent(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s content: %v", name, err)
+	}
+	return content, nil
+}
+
+// 写入仓库数据目录下的文件
+func (s *githubStorage) WriteFile(name string, data []byte) error {
+	ctx := context.Background()
+
+	// 配额不足时跳过非必要的文件
+	if s.degraded() && !essentialGithubFile(name) {
+		fmt.Printf("GitHub API budget low, skipping %s\n", name)
+		return nil
+	}
+
+	filePath := s.repoPath(name)
+	if !unbatchedGithubFile(name) && s.batch.put(filePath, data) {
+		return nil
+	}
+	file, err := s.getFile(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("error checking %s in GitHub: %v", name, err)
+	}
+
+	// 内容没有变化时不提交
+	if file != nil && s.unchanged(ctx, file, filePath, data) {
+		debugf("%s unchanged, skipping commit", filePath)
+		return nil
+	}
+
+	return s.putFile(ctx, file, filePath, path.Base(filePath), data)
+}
+
+// 追加日志到 GitHub 仓库中的 error.log 文件
+func (s *githubStorage) AppendLog(message string) error {
+	ctx := context.Background()
+
+	// 配额不足时不写入，日志已输出到标准输出
+	if s.degraded() {
+		return nil
+	}
+
+	filePath := s.repoPath("error.log")
+
+	// 尝试获取 error.log 文件
+	existingLog, ok := s.batch.get(filePath)
+	var file *github.RepositoryContent
+	if !ok {
+		var err error
+		file, err = s.getFile(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("error checking error.log in GitHub: %v", err)
+		}
+
+		// 如果文件存在，则获取文件内容并追加日志，超过上限时轮转
+		if file != nil {
+			existingLog, err = s.fileContent(ctx, file)
+			if err != nil {
+				return fmt.Errorf("error decoding error.log content: %v", err)
+			}
+		}
+	}
+	fileContent, err := appendErrorLog(s.config, s, existingLog, message)
+	if err != nil {
+		return err
+	}
+
+	if s.batch.put(filePath, fileContent) {
+		return nil
+	}
+	return s.putFile(ctx, file, filePath, "error.log", fileContent)
+}
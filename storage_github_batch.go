@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// 一次运行中暂存的写入，运行结束时通过 Git Data API 一次提交，见 GITHUB_SINGLE_COMMIT
+type githubBatch struct {
+	mu     sync.Mutex
+	active bool
+	// 仓库路径 -> 内容
+	files map[string][]byte
+	// 首次写入的顺序，用于提交信息
+	order []string
+}
+
+// 开始暂存写入
+func (b *githubBatch) start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.active = true
+	b.files = map[string][]byte{}
+	b.order = nil
+}
+
+// 读取暂存的文件，本次运行没有写入过时 ok 为 false
+func (b *githubBatch) get(filePath string) (data []byte, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok = b.files[filePath]
+	return data, ok
+}
+
+// 暂存一次写入，没有在暂存时返回 false
+func (b *githubBatch) put(filePath string, data []byte) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.active {
+		return false
+	}
+	if _, ok := b.files[filePath]; !ok {
+		b.order = append(b.order, filePath)
+	}
+	b.files[filePath] = data
+	return true
+}
+
+// 结束暂存，按首次写入的顺序返回暂存的文件
+func (b *githubBatch) take() []githubFile {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	files := make([]githubFile, 0, len(b.order))
+	for _, filePath := range b.order {
+		files = append(files, githubFile{path: filePath, content: b.files[filePath]})
+	}
+	b.active = false
+	b.files = nil
+	b.order = nil
+	return files
+}
+
+// 不合并、立即提交的文件。发件箱和历史必须在发送通知之前保存到仓库，
+// 否则通知发出后进程中断或合并提交失败，下次运行会重新发现同样的文章并再次通知
+func unbatchedGithubFile(name string) bool {
+	switch name {
+	case "outbox.json", "history.json":
+		return true
+	}
+	return false
+}
+
+// 开始合并本次运行的写入，未开启 GITHUB_SINGLE_COMMIT 时每次写入单独提交
+func (s *githubStorage) beginBatch() {
+	if s.config.GithubSingleCommit {
+		s.batch.start()
+	}
+}
+
+// 在一次提交中写入本次运行暂存的全部文件
+func (s *githubStorage) commitBatch() error {
+	files := s.batch.take()
+	if len(files) == 0 {
+		return nil
+	}
+	if err := s.commitFiles(context.Background(), s.config.GithubBranch, batchCommitMessage, files); err != nil {
+		return fmt.Errorf("error committing %d files to GitHub: %v", len(files), err)
+	}
+	return nil
+}
+
+// 合并提交的提交信息：标题列出前几个文件名，正文列出全部文件
+func batchCommitMessage(files []githubFile) string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = path.Base(file.path)
+	}
+	if len(names) <= 3 {
+		return "Update " + strings.Join(names, ", ")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Update %s and %d more files\n\n", strings.Join(names[:3], ", "), len(names)-3)
+	for _, file := range files {
+		fmt.Fprintf(&b, "- %s\n", file.path)
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// GitHub API 调用配额（每小时），根据每次响应中的 X-RateLimit-* 更新
+type githubBudget struct {
+	mu sync.Mutex
+
+	// 本次运行的调用次数
+	calls int
+	// 上一次运行的调用次数，用于预估
+	lastRunCalls int
+	// 最近一次响应中的配额
+	rate  github.Rate
+	known bool
+	// 配额不足，已降级
+	low bool
+	// 本次运行因限流等待的次数和总时间
+	waits  int
+	waited time.Duration
+}
+
+// 记录一次 API 调用
+func (b *githubBudget) observe(resp *github.Response) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.calls++
+	if resp != nil && resp.Rate.Limit > 0 {
+		b.rate = resp.Rate
+		b.known = true
+	}
+}
+
+// 记录一次限流等待
+func (b *githubBudget) observeWait(wait time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.waits++
+	b.waited += wait
+}
+
+// 降级时仍然写入的文件：文章数据、RSS 列表以及影响下次运行判断的状态
+func essentialGithubFile(name string) bool {
+	switch name {
+	case "rss_data.json", "rss_feeds.txt", "feed_health.json", "history.json", "outbox.json":
+		return true
+	}
+	return false
+}
+
+// 是否已降级：运行开始时配额不足，或运行中剩余配额低于保留值
+func (s *githubStorage) degraded() bool {
+	s.budget.mu.Lock()
+	defer s.budget.mu.Unlock()
+	return s.budget.low || (s.budget.known && s.budget.rate.Remaining < s.config.GithubCallReserve)
+}
+
+// 运行开始时查询剩余配额（查询本身不消耗配额），预计不足时警告并降级
+func (s *githubStorage) checkBudget() error {
+	limits, _, err := s.client.RateLimits(context.Background())
+	if err != nil {
+		return fmt.Errorf("error fetching GitHub rate limits: %v", err)
+	}
+
+	s.budget.mu.Lock()
+	defer s.budget.mu.Unlock()
+
+	// 开始新一次运行
+	if s.budget.calls > 0 {
+		s.budget.lastRunCalls = s.budget.calls
+	}
+	s.budget.calls = 0
+	s.budget.waits, s.budget.waited = 0, 0
+	s.budget.low = false
+	if limits.Core == nil {
+		return nil
+	}
+	s.budget.rate = *limits.Core
+	s.budget.known = true
+
+	estimate := s.config.GithubCallEstimate
+	if s.budget.lastRunCalls > 0 {
+		estimate = s.budget.lastRunCalls
+	}
+
+	if s.budget.rate.Remaining-estimate < s.config.GithubCallReserve {
+		s.budget.low = true
+		fmt.Printf("[%s] [GitHub API budget] %d of %d calls remaining until %s, this run needs about %d; skipping log and stats writes\n",
+			localTime().Format("Mon Jan 2 15:04:2006"), s.budget.rate.Remaining, s.budget.rate.Limit,
+			s.budget.rate.Reset.In(timeZone).Format("15:04"), estimate)
+	}
+	return nil
+}
+
+// 本次运行的调用情况
+func (s *githubStorage) budgetReport() string {
+	s.budget.mu.Lock()
+	defer s.budget.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "GitHub API: %d calls this run", s.budget.calls)
+	if s.budget.known {
+		fmt.Fprintf(&b, ", %d of %d remaining, resets in %v", s.budget.rate.Remaining, s.budget.rate.Limit,
+			time.Until(s.budget.rate.Reset.Time).Round(time.Minute))
+	}
+	if s.budget.waits > 0 {
+		fmt.Fprintf(&b, ", rate limited %d times (waited %v)", s.budget.waits, s.budget.waited.Round(time.Second))
+	}
+	if s.budget.low {
+		b.WriteString(" (degraded)")
+	}
+	return b.String()
+}
+
+// 运行摘要 run.log 中的配额字段
+func (s *githubStorage) budgetFields() string {
+	s.budget.mu.Lock()
+	defer s.budget.mu.Unlock()
+
+	fields := fmt.Sprintf("github_calls=%d", s.budget.calls)
+	if s.budget.known {
+		fields += fmt.Sprintf(" github_remaining=%d/%d", s.budget.rate.Remaining, s.budget.rate.Limit)
+	}
+	if s.budget.waits > 0 {
+		fields += fmt.Sprintf(" github_rate_limited=%d", s.budget.waits)
+	}
+	return fields
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// Contents API 只返回 1MB 以内文件的内容，写入较大的文件也容易失败
+const githubContentsLimit = 1 << 20
+
+// 超过 Contents API 上限或不是 UTF-8 文本的文件改用 Git Data API 写入
+func needsGitDataAPI(content []byte) bool {
+	return len(content) > githubContentsLimit || !utf8.Valid(content)
+}
+
+// 读取 Contents API 返回的文件内容。超过 1MB 的文件不包含内容（encoding 为 none），通过 blob 读取
+func (s *githubStorage) fileContent(ctx context.Context, file *github.RepositoryContent) ([]byte, error) {
+	if file.GetEncoding() != "none" {
+		content, err := file.GetContent()
+		return []byte(content), err
+	}
+
+	data, resp, err := s.client.Git.GetBlobRaw(ctx, s.config.GithubName, s.config.GithubRepository, file.GetSHA())
+	s.budget.observe(resp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching blob %s: %v", file.GetSHA(), err)
+	}
+	return data, nil
+}
+
+// 通过 Git Data API 提交的文件
+type githubFile struct {
+	path    string
+	content []byte
+}
+
+// 通过 Git Data API 提交单个文件
+func (s *githubStorage) commitFile(ctx context.Context, branch string, filePath string, message string, content []byte) error {
+	return s.commitFiles(ctx, branch, func([]githubFile) string { return message }, []githubFile{{path: filePath, content: content}})
+}
+
+// 通过 Git Data API 在一次提交中写入多个文件：去掉没有变化的文件后创建 tree，再提交并移动分支。
+// 较小的 UTF-8 文本直接放在 tree 中，其余先创建 blob。message 根据实际提交的文件生成提交信息
+func (s *githubStorage) commitFiles(ctx context.Context, branch string, message func(files []githubFile) string, files []githubFile) error {
+	owner, repo := s.config.GithubName, s.config.GithubRepository
+
+	ref, resp, err := s.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error fetching %s: %v", branch, err)
+	}
+	parent, resp, err := s.client.Git.GetCommit(ctx, owner, repo, ref.Object.GetSHA())
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error fetching commit %s: %v", ref.Object.GetSHA(), err)
+	}
+
+	// 内容都没有变化时不提交
+	files, err = s.changedFiles(ctx, parent.Tree.GetSHA(), files)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("GitHub: no changes, skipping commit")
+		return nil
+	}
+
+	entries := make([]*github.TreeEntry, 0, len(files))
+	for _, file := range files {
+		entry := &github.TreeEntry{
+			Path: github.String(file.path),
+			Mode: github.String("100644"),
+			Type: github.String("blob"),
+		}
+		if needsGitDataAPI(file.content) {
+			blob, resp, err := s.client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+				Content:  github.String(base64.StdEncoding.EncodeToString(file.content)),
+				Encoding: github.String("base64"),
+			})
+			s.budget.observe(resp)
+			if err != nil {
+				return fmt.Errorf("error creating blob for %s: %v", file.path, err)
+			}
+			entry.SHA = blob.SHA
+		} else {
+			entry.Content = github.String(string(file.content))
+		}
+		entries = append(entries, entry)
+	}
+
+	tree, resp, err := s.client.Git.CreateTree(ctx, owner, repo, parent.Tree.GetSHA(), entries)
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error creating tree: %v", err)
+	}
+
+	commit, resp, err := s.client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message:   github.String(attributeCommit(message(files))),
+		Tree:      tree,
+		Parents:   []*github.Commit{parent},
+		Author:    s.commitAuthor(),
+		Committer: s.commitAuthor(),
+	})
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error creating commit: %v", err)
+	}
+
+	ref.Object.SHA = commit.SHA
+	_, resp, err = s.client.Git.UpdateRef(ctx, owner, repo, ref, false)
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error updating %s: %v", branch, err)
+	}
+	return nil
+}
+
+// 内容对应的 git blob SHA，与仓库中文件的 SHA 相同时内容没有变化
+func gitBlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SHA 不同时是否还要比较 JSON 内容：文章数据只有格式（缩进、字段顺序）不同时不重新提交
+func (s *githubStorage) comparesJSON(filePath string) bool {
+	return filePath == s.repoPath("rss_data.json")
+}
+
+// 两段 JSON 是否表示相同的数据
+func jsonEqual(old, content []byte) bool {
+	var a, b interface{}
+	if json.Unmarshal(old, &a) != nil || json.Unmarshal(content, &b) != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// 写入的内容与仓库中的文件是否相同
+func (s *githubStorage) unchanged(ctx context.Context, file *github.RepositoryContent, filePath string, content []byte) bool {
+	if file.GetSHA() == gitBlobSHA(content) {
+		return true
+	}
+	if !s.comparesJSON(filePath) {
+		return false
+	}
+	old, err := s.fileContent(ctx, file)
+	return err == nil && jsonEqual(old, content)
+}
+
+// 去掉与分支上内容相同的文件，避免没有变化的提交
+func (s *githubStorage) changedFiles(ctx context.Context, treeSHA string, files []githubFile) ([]githubFile, error) {
+	tree, resp, err := s.client.Git.GetTree(ctx, s.config.GithubName, s.config.GithubRepository, treeSHA, true)
+	s.budget.observe(resp)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching tree %s: %v", treeSHA, err)
+	}
+	// 仓库过大时 tree 会被截断，不在其中的文件按有变化处理
+	existing := map[string]string{}
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			existing[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+
+	var changed []githubFile
+	for _, file := range files {
+		sha, ok := existing[file.path]
+		if ok && sha == gitBlobSHA(file.content) {
+			continue
+		}
+		if ok && s.comparesJSON(file.path) {
+			old, resp, err := s.client.Git.GetBlobRaw(ctx, s.config.GithubName, s.config.GithubRepository, sha)
+			s.budget.observe(resp)
+			if err == nil && jsonEqual(old, file.content) {
+				continue
+			}
+		}
+		changed = append(changed, file)
+	}
+	return changed, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// 以 Pull Request 的方式提交 RSS 列表的修改。分支名由新内容的哈希决定，
+// 同样的修改已有分支（PR 尚未合并）时不再重复提交，例如每次运行都会尝试的 HTTPS 升级。
+// 提交了 PR 或 PR 已存在时返回 *feedsProposedError，调用方不能当作列表已经修改
+func (s *githubStorage) proposeFeeds(lines []string) error {
+	ctx := context.Background()
+	owner, repo := s.config.GithubName, s.config.GithubRepository
+	filePath := s.repoPath("rss_feeds.txt")
+	content := []byte(strings.Join(lines, "\n") + "\n")
+
+	sum := sha256.Sum256(content)
+	branch := "feeds/update-" + hex.EncodeToString(sum[:])[:8]
+
+	_, resp, err := s.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	s.budget.observe(resp)
+	if err == nil {
+		fmt.Printf("Pull request branch %s already exists, skipping\n", branch)
+		return &feedsProposedError{link: branch}
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error checking branch %s: %v", branch, err)
+	}
+
+	// 当前的 RSS 列表，用于生成 PR 说明
+	current, err := s.getFile(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("error fetching %s from GitHub: %v", filePath, err)
+	}
+	var oldLines []string
+	if current != nil {
+		text, err := s.fileContent(ctx, current)
+		if err != nil {
+			return fmt.Errorf("error decoding %s content: %v", filePath, err)
+		}
+		oldLines = strings.Split(strings.TrimRight(string(text), "\n"), "\n")
+	}
+	added, removed := diffLines(oldLines, lines)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	base, resp, err := s.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+s.config.GithubBranch)
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error fetching %s: %v", s.config.GithubBranch, err)
+	}
+	_, resp, err = s.client.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: base.Object.SHA},
+	})
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error creating branch %s: %v", branch, err)
+	}
+
+	// 分支刚从 GITHUB_BRANCH 创建，文件与其上的相同
+	if err := s.putFileOnBranch(ctx, branch, current, filePath, "rss_feeds.txt", content); err != nil {
+		return err
+	}
+
+	pr, resp, err := s.client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(fmt.Sprintf("Update rss_feeds.txt (+%d -%d)", len(added), len(removed))),
+		Head:  github.String(branch),
+		Base:  github.String(s.config.GithubBranch),
+		Body:  github.String(feedsPullRequestBody(added, removed)),
+	})
+	s.budget.observe(resp)
+	if err != nil {
+		return fmt.Errorf("error creating pull request: %v", err)
+	}
+	fmt.Printf("Opened pull request for rss_feeds.txt: %s\n", pr.GetHTMLURL())
+	return &feedsProposedError{link: pr.GetHTMLURL()}
+}
+
+// 按行比较两份列表，返回新增和删除的行，忽略空行
+func diffLines(oldLines []string, newLines []string) (added []string, removed []string) {
+	count := map[string]int{}
+	for _, line := range oldLines {
+		count[line]++
+	}
+	for _, line := range newLines {
+		if count[line] > 0 {
+			count[line]--
+		} else if strings.TrimSpace(line) != "" {
+			added = append(added, line)
+		}
+	}
+	for _, line := range oldLines {
+		if count[line] > 0 && strings.TrimSpace(line) != "" {
+			removed = append(removed, line)
+			count[line]--
+		}
+	}
+	return added, removed
+}
+
+// PR 说明：列出新增和删除的行
+func feedsPullRequestBody(added []string, removed []string) string {
+	var b strings.Builder
+	b.WriteString("Automated change to the feed list. Review and merge to apply it.\n\n```diff\n")
+	for _, line := range removed {
+		b.WriteString("- " + line + "\n")
+	}
+	for _, line := range added {
+		b.WriteString("+ " + line + "\n")
+	}
+	b.WriteString("```\n")
+	if currentMaintainer != "" {
+		b.WriteString("\nRequested by @" + currentMaintainer + ".\n")
+	}
+	return b.String()
+}
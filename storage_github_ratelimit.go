@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 触发限流后最多重试的次数
+const githubRateLimitRetries = 3
+
+// 处理 GitHub API 的限流响应：主限流（X-RateLimit-Remaining 为 0）等到 X-RateLimit-Reset，
+// 次级限流按 Retry-After 等待，没有 Retry-After 时从 1 分钟开始指数退避。
+// 需要等待的时间超过 GITHUB_RATE_LIMIT_WAIT 时不再等待，由 go-github 返回限流错误
+type githubRateLimitTransport struct {
+	base    http.RoundTripper
+	maxWait time.Duration
+	budget  *githubBudget
+}
+
+func (t *githubRateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+
+		wait, reason, limited := githubRateLimitWait(resp, attempt)
+		if !limited || attempt >= githubRateLimitRetries || wait > t.maxWait {
+			return resp, nil
+		}
+		// 请求体无法重新读取时不重试
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		fmt.Printf("[GitHub API] %s, retrying %s %s in %v\n", reason, req.Method, req.URL.Path, wait.Round(time.Second))
+		t.budget.observeWait(wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// 判断响应是否为限流，返回需要等待的时间和原因
+func githubRateLimitWait(resp *http.Response, attempt int) (time.Duration, string, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, "", false
+	}
+
+	// 次级限流通常带有 Retry-After
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second, "secondary rate limit", true
+		}
+	}
+
+	// 主限流：等到配额重置
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait := time.Until(time.Unix(reset, 0)) + time.Second
+			if wait < time.Second {
+				wait = time.Second
+			}
+			return wait, "rate limit exceeded", true
+		}
+	}
+
+	// 没有 Retry-After 的次级限流只能从响应内容判断，读取后放回响应体
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err == nil && strings.Contains(strings.ToLower(string(data)), "secondary rate limit") {
+		return time.Minute << attempt, "secondary rate limit", true
+	}
+	return 0, "", false
+}
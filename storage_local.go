@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	registerStorage("local", newLocalStorage)
+}
+
+// 纯本地存储：读取本地 rss_feeds.txt，数据文件写入 OUTPUT_DIR，
+// 不需要任何凭据，适合离线使用或交给 rsync、Netlify、Vercel 等静态托管流程发布
+type localStorage struct {
+	config Config
+}
+
+func newLocalStorage(config Config) (Storage, error) {
+	if config.OutputDir == "" {
+		return nil, fmt.Errorf("OUTPUT_DIR is required for the local backend")
+	}
+	if err := os.MkdirAll(config.OutputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %v", err)
+	}
+	return &localStorage{config: config}, nil
+}
+
+// 从本地 rss_feeds.txt 读取 RSS
+func (s *localStorage) ReadFeeds() ([]string, error) {
+	return readFeedsFromFile(s.config.FeedsFile)
+}
+
+// 写回本地 rss_feeds.txt
+func (s *localStorage) WriteFeeds(lines []string) error {
+	return writeFeedsToFile(s.config.FeedsFile, lines)
+}
+
+// 将爬虫抓取的数据保存到 OUTPUT_DIR/rss_data.json
+func (s *localStorage) SaveArticles(articles []Article) error {
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+	return s.WriteFile("rss_data.json", jsonData)
+}
+
+// 读取 OUTPUT_DIR 下的文件
+func (s *localStorage) ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.config.OutputDir, filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", name, err)
+	}
+	return data, nil
+}
+
+// 写入 OUTPUT_DIR 下的文件。先写入临时文件再重命名，
+// 避免同步或托管程序读到写了一半的文件
+func (s *localStorage) WriteFile(name string, data []byte) error {
+	target := filepath.Join(s.config.OutputDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("error creating directory for %s: %v", name, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing %s: %v", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing %s: %v", name, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("error writing %s: %v", name, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("error writing %s: %v", name, err)
+	}
+	return nil
+}
+
+// 追加日志到 OUTPUT_DIR/error.log
+func (s *localStorage) AppendLog(message string) error {
+	existingLog, err := s.ReadFile("error.log")
+	if err != nil {
+		return err
+	}
+
+	// 将新的错误信息追加到现有的日志内容中，超过上限时轮转
+	newLog, err := appendErrorLog(s.config, s, existingLog, message)
+	if err != nil {
+		return err
+	}
+	return s.WriteFile("error.log", newLog)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 同时使用多个存储后端，STORAGE_BACKEND 为逗号分隔的列表，例如 github,cos。
+// 第一个是主后端，RSS 列表、日志和读取都使用主后端；文章数据和其他文件写入所有后端。
+// 只有主后端写入失败时返回错误，其他后端（镜像）的失败分别记录，不影响本次发布
+type multiStorage struct {
+	names    []string
+	backends []Storage
+
+	mu sync.Mutex
+	// 本次运行各后端的写入次数和失败次数
+	writes   []int
+	failures []int
+}
+
+func newMultiStorage(config Config, names []string) (Storage, error) {
+	s := &multiStorage{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		backendConfig := config
+		backendConfig.StorageBackend = name
+		backend, err := newStorage(backendConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		s.names = append(s.names, name)
+		s.backends = append(s.backends, backend)
+	}
+	if len(s.backends) == 0 {
+		return nil, fmt.Errorf("no storage backend in %q", config.StorageBackend)
+	}
+	s.writes = make([]int, len(s.backends))
+	s.failures = make([]int, len(s.backends))
+	return s, nil
+}
+
+func (s *multiStorage) primary() Storage {
+	return s.backends[0]
+}
+
+func (s *multiStorage) ReadFeeds() ([]string, error) {
+	return s.primary().ReadFeeds()
+}
+
+func (s *multiStorage) WriteFeeds(lines []string) error {
+	return s.primary().WriteFeeds(lines)
+}
+
+// 在所有后端执行操作，返回主后端的错误，其他后端的错误记入日志。
+// count 为 true 时计入各后端的写入和失败次数
+func (s *multiStorage) each(count bool, op func(backend Storage) error) error {
+	var primaryErr error
+	for i, backend := range s.backends {
+		err := op(backend)
+		if count {
+			s.mu.Lock()
+			s.writes[i]++
+			if err != nil {
+				s.failures[i]++
+			}
+			s.mu.Unlock()
+		}
+
+		switch {
+		case err == nil:
+		case i == 0:
+			primaryErr = fmt.Errorf("%s: %v", s.names[i], err)
+		default:
+			logError(s, "Storage mirror write error", "backend", s.names[i], "err", err)
+		}
+	}
+	return primaryErr
+}
+
+func (s *multiStorage) SaveArticles(articles []Article) error {
+	return s.each(true, func(backend Storage) error { return backend.SaveArticles(articles) })
+}
+
+func (s *multiStorage) AppendLog(message string) error {
+	return s.primary().AppendLog(message)
+}
+
+func (s *multiStorage) ReadFile(name string) ([]byte, error) {
+	return s.primary().ReadFile(name)
+}
+
+func (s *multiStorage) WriteFile(name string, data []byte) error {
+	return s.each(true, func(backend Storage) error { return backend.WriteFile(name, data) })
+}
+
+func (s *multiStorage) beginBatch() {
+	s.mu.Lock()
+	for i := range s.backends {
+		s.writes[i], s.failures[i] = 0, 0
+	}
+	s.mu.Unlock()
+	for _, backend := range s.backends {
+		beginStorageBatch(backend)
+	}
+}
+
+func (s *multiStorage) commitBatch() error {
+	return s.each(false, commitStorageBatch)
+}
+
+func (s *multiStorage) checkBudget() error {
+	for _, backend := range s.backends {
+		checkStorageBudget(backend)
+	}
+	return nil
+}
+
+func (s *multiStorage) budgetReport() string {
+	var reports []string
+	for _, backend := range s.backends {
+		if b, ok := backend.(budgetedStorage); ok {
+			reports = append(reports, b.budgetReport())
+		}
+	}
+	return strings.Join(reports, "\n")
+}
+
+func (s *multiStorage) budgetFields() string {
+	var fields []string
+	for _, backend := range s.backends {
+		if b, ok := backend.(budgetedStorage); ok {
+			fields = append(fields, b.budgetFields())
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// 发布后从每个后端读回 rss_data.json 并比较 SHA-256，某个后端的上传静默失败时记录错误并发送提醒。
+// 前端读取的副本（例如 COS）与主后端（例如 GitHub）不一致时需要人工处理
+func verifyStorageCopies(config Config, store Storage) {
+	m, ok := store.(*multiStorage)
+	if !ok || !config.VerifyCopies {
+		return
+	}
+
+	hashes := make([]string, len(m.backends))
+	for i, backend := range m.backends {
+		data, err := backend.ReadFile("rss_data.json")
+		if err != nil {
+			logError(store, "Verify storage copy error", "backend", m.names[i], "err", err)
+			return
+		}
+		if data == nil {
+			hashes[i] = "missing"
+			continue
+		}
+		sum := sha256.Sum256(data)
+		hashes[i] = hex.EncodeToString(sum[:])[:12]
+	}
+
+	diverged := false
+	parts := make([]string, len(hashes))
+	for i, hash := range hashes {
+		parts[i] = m.names[i] + "=" + hash
+		if hash != hashes[0] {
+			diverged = true
+		}
+	}
+	if !diverged {
+		debugf("Storage copies of rss_data.json match: %s", strings.Join(parts, " "))
+		return
+	}
+
+	logError(store, "Storage copies differ", "file", "rss_data.json", "sha256", strings.Join(parts, " "))
+	sendAlert(config, store, "divergence", fmt.Sprintf("rss_data.json differs between storage backends: %s", strings.Join(parts, ", ")))
+}
+
+// 运行结束时输出各后端的写入结果，有镜像写入失败时发送提醒
+func reportStorageTargets(config Config, store Storage) {
+	m, ok := store.(*multiStorage)
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	var failed []string
+	for i, name := range m.names {
+		fmt.Printf("Storage %s: %d writes, %d failed\n", name, m.writes[i], m.failures[i])
+		if i > 0 && m.failures[i] > 0 {
+			failed = append(failed, fmt.Sprintf("%s (%d of %d)", name, m.failures[i], m.writes[i]))
+		}
+	}
+	m.mu.Unlock()
+
+	if len(failed) > 0 {
+		sendAlert(config, store, "mirror", fmt.Sprintf("Writes failed on storage mirrors: %s", strings.Join(failed, ", ")))
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// 只读存储：读取委托给实际的后端，写入只保存在内存中，日志输出到标准输出。
+// 用于在不修改任何数据的情况下完整运行一次
+type readOnlyStorage struct {
+	Storage
+
+	// 非 nil 时替代后端中的 RSS 列表
+	feeds []string
+	// 运行中修改过 RSS 列表
+	feedsChanged bool
+
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newReadOnlyStorage(store Storage, feeds []string) *readOnlyStorage {
+	return &readOnlyStorage{Storage: store, feeds: feeds, files: map[string][]byte{}}
+}
+
+func (s *readOnlyStorage) ReadFeeds() ([]string, error) {
+	s.mu.Lock()
+	feeds := s.feeds
+	s.mu.Unlock()
+	if feeds != nil {
+		return feeds, nil
+	}
+	return s.Storage.ReadFeeds()
+}
+
+func (s *readOnlyStorage) WriteFeeds(lines []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feeds = lines
+	s.feedsChanged = true
+	return nil
+}
+
+func (s *readOnlyStorage) SaveArticles(articles []Article) error {
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+	return s.WriteFile("rss_data.json", jsonData)
+}
+
+func (s *readOnlyStorage) AppendLog(message string) error {
+	fmt.Printf("[log] %s\n", message)
+	return nil
+}
+
+// 优先读取本次运行中写入的文件
+func (s *readOnlyStorage) ReadFile(name string) ([]byte, error) {
+	s.mu.Lock()
+	data, ok := s.files[name]
+	s.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+	return s.Storage.ReadFile(name)
+}
+
+func (s *readOnlyStorage) WriteFile(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = data
+	return nil
+}
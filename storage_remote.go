@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 远程目录的读写，SFTP、WebDAV 等只需要上传和下载文件的后端实现这个接口
+type remoteFiles interface {
+	// 读取远程目录下的文件，文件不存在时返回 nil
+	get(name string) ([]byte, error)
+	// 写入远程目录下的文件，按需创建上级目录
+	put(name string, data []byte) error
+}
+
+// 通过 remoteFiles 发布数据的存储后端，RSS 列表默认读取本地文件，其余与 local 后端相同
+type remoteStorage struct {
+	config Config
+	// 后端名称，用于错误信息
+	kind  string
+	files remoteFiles
+	// 远程目录中的 RSS 列表，为空时读取本地 rss_feeds.txt
+	feedsFile string
+}
+
+// 读取 RSS 列表：设置了 feedsFile 时从远程目录读取，否则读取本地 rss_feeds.txt
+func (s *remoteStorage) ReadFeeds() ([]string, error) {
+	if s.feedsFile == "" {
+		return readFeedsFromFile(s.config.FeedsFile)
+	}
+
+	data, err := s.ReadFile(s.feedsFile)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%s not found on %s", s.feedsFile, s.kind)
+	}
+	var feeds []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		feeds = append(feeds, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", s.feedsFile, err)
+	}
+	return feeds, nil
+}
+
+// 写回 RSS 列表
+func (s *remoteStorage) WriteFeeds(lines []string) error {
+	if s.feedsFile == "" {
+		return writeFeedsToFile(s.config.FeedsFile, lines)
+	}
+	return s.WriteFile(s.feedsFile, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// 上传爬虫抓取的数据
+func (s *remoteStorage) SaveArticles(articles []Article) error {
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+	return s.WriteFile("rss_data.json", jsonData)
+}
+
+// 下载远程目录下的文件
+func (s *remoteStorage) ReadFile(name string) ([]byte, error) {
+	data, err := s.files.get(name)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s from %s: %v", name, s.kind, err)
+	}
+	return data, nil
+}
+
+// 上传文件到远程目录
+func (s *remoteStorage) WriteFile(name string, data []byte) error {
+	if err := s.files.put(name, data); err != nil {
+		return fmt.Errorf("error saving %s to %s: %v", name, s.kind, err)
+	}
+	return nil
+}
+
+// 追加日志到远程目录的 error.log
+func (s *remoteStorage) AppendLog(message string) error {
+	existingLog, err := s.ReadFile("error.log")
+	if err != nil {
+		return err
+	}
+
+	// 将新的错误信息追加到现有的日志内容中，超过上限时轮转
+	newLog, err := appendErrorLog(s.config, s, existingLog, message)
+	if err != nil {
+		return err
+	}
+	return s.WriteFile("error.log", newLog)
+}
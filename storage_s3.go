@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerStorage("s3", newS3Storage)
+}
+
+// S3 兼容存储的请求客户端，适用于 Amazon S3、Cloudflare R2、MinIO、Backblaze B2
+var s3HTTPClient = &http.Client{Timeout: time.Second * 30}
+
+// 按 S3 的要求对 URI 进行编码，只保留非保留字符
+func s3URIEncode(s string, encodeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			buf.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&buf, "%%%02X", c)
+	}
+	return buf.String()
+}
+
+// 计算 HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// 计算 SHA256 并以十六进制表示
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// 构造使用 AWS Signature Version 4 签名的请求，使用 path-style 地址：endpoint/bucket/key
+func newS3Request(config Config, method string, key string, body []byte) (*http.Request, error) {
+	canonicalURI := "/" + s3URIEncode(config.S3Bucket, true) + "/" + s3URIEncode(key, false)
+	endpoint := strings.TrimRight(config.S3Endpoint, "/")
+
+	req, err := http.NewRequest(method, endpoint+canonicalURI, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	// 规范请求
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	// 待签名字符串
+	scope := shortDate + "/" + config.S3Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	// 派生签名密钥并计算签名
+	signingKey := hmacSHA256([]byte("AWS4"+config.S3SecretKey), shortDate)
+	signingKey = hmacSHA256(signingKey, config.S3Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		config.S3AccessKey, scope, signedHeaders, signature))
+
+	return req, nil
+}
+
+// 从 S3 下载对象，对象不存在时返回 nil
+func getFromS3(config Config, key string) ([]byte, error) {
+	req, err := newS3Request(config, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s3HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, string(body))
+	}
+
+	return body, nil
+}
+
+// 上传对象到 S3
+func putToS3(config Config, key string, data []byte, contentType string) error {
+	req, err := newS3Request(config, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s3HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// S3 兼容存储，数据保存在存储桶的 rss/ 目录下，RSS 列表读取本地文件
+type s3Storage struct {
+	config Config
+}
+
+func newS3Storage(config Config) (Storage, error) {
+	if config.S3Endpoint == "" || config.S3Bucket == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for the s3 backend")
+	}
+	return &s3Storage{config: config}, nil
+}
+
+// 从 rss_feeds.txt 文件中读取 RSS
+func (s *s3Storage) ReadFeeds() ([]string, error) {
+	return readFeedsFromFile(s.config.FeedsFile)
+}
+
+// 写回本地 rss_feeds.txt
+func (s *s3Storage) WriteFeeds(lines []string) error {
+	return writeFeedsToFile(s.config.FeedsFile, lines)
+}
+
+// 将爬虫抓取的数据保存到 S3
+func (s *s3Storage) SaveArticles(articles []Article) error {
+	jsonData, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+
+	err = putToS3(s.config, "rss/rss_data.json", jsonData, "application/json")
+	if err != nil {
+		return fmt.Errorf("error saving data to S3: %v", err)
+	}
+
+	return nil
+}
+
+// 读取存储桶 rss/ 目录下的文件
+func (s *s3Storage) ReadFile(name string) ([]byte, error) {
+	data, err := getFromS3(s.config, "rss/"+name)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s from S3: %v", name, err)
+	}
+	return data, nil
+}
+
+// 写入存储桶 rss/ 目录下的文件
+func (s *s3Storage) WriteFile(name string, data []byte) error {
+	if err := putToS3(s.config, "rss/"+name, data, contentTypeOf(name)); err != nil {
+		return fmt.Errorf("error saving %s to S3: %v", name, err)
+	}
+	return nil
+}
+
+// 追加日志到 S3 的 error.log 文件
+func (s *s3Storage) AppendLog(message string) error {
+
+	// 尝试获取 error.log 文件，不存在时从空日志开始
+	existingLog, err := getFromS3(s.config, "rss/error.log")
+	if err != nil {
+		return fmt.Errorf("error downloading error.log from S3: %v", err)
+	}
+
+	// 将新的错误信息追加到现有的日志内容中，超过上限时轮转
+	newLog, err := appendErrorLog(s.config, s, existingLog, message)
+	if err != nil {
+		return err
+	}
+
+	err = putToS3(s.config, "rss/error.log", newLog, "text/plain; charset=utf-8")
+	if err != nil {
+		return fmt.Errorf("error saving error log to S3: %v", err)
+	}
+
+	return nil
+}
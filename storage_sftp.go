@@ -0,0 +1,453 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func init() {
+	registerStorage("sftp", newSFTPStorage)
+}
+
+// SFTP 协议（版本 3）的报文类型，只实现上传和下载用到的部分
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpRead    = 5
+	sftpWrite   = 6
+	sftpMkdir   = 14
+	sftpStatus  = 101
+	sftpHandle  = 102
+	sftpData    = 103
+)
+
+// 打开文件的标志
+const (
+	sftpFlagRead  = 0x01
+	sftpFlagWrite = 0x02
+	sftpFlagCreat = 0x08
+	sftpFlagTrunc = 0x10
+)
+
+// SFTP 状态码
+const (
+	sftpOK         = 0
+	sftpEOF        = 1
+	sftpNoSuchFile = 2
+)
+
+// 每次读写的最大字节数，OpenSSH 接受的报文上限约为 256KB
+const sftpChunkSize = 32 << 10
+
+// 服务器返回的错误状态
+type sftpStatusError struct {
+	code    uint32
+	message string
+}
+
+func (e *sftpStatusError) Error() string {
+	return fmt.Sprintf("sftp status %d: %s", e.code, e.message)
+}
+
+// 通过 SFTP 发布数据，适用于只提供 SFTP 的传统虚拟主机
+type sftpFiles struct {
+	config Config
+
+	// 连接在首次使用时建立，一次运行内复用，出错后下次使用时重新连接
+	mu     sync.Mutex
+	client *ssh.Client
+	conn   *sftpConn
+}
+
+func newSFTPStorage(config Config) (Storage, error) {
+	if config.SFTPAddr == "" || config.SFTPUser == "" {
+		return nil, fmt.Errorf("SFTP_ADDR and SFTP_USER are required for the sftp backend")
+	}
+	if config.SFTPPassword == "" && config.SFTPKeyFile == "" {
+		return nil, fmt.Errorf("SFTP_PASSWORD or SFTP_KEY_FILE is required for the sftp backend")
+	}
+	return &remoteStorage{config: config, kind: "SFTP", files: &sftpFiles{config: config}}, nil
+}
+
+// 远程路径，相对于 SFTP_DIR
+func (f *sftpFiles) remotePath(name string) string {
+	return path.Join(f.config.SFTPDir, name)
+}
+
+func (f *sftpFiles) get(name string) ([]byte, error) {
+	var data []byte
+	err := f.with(func(c *sftpConn) error {
+		var err error
+		data, err = c.readFile(f.remotePath(name))
+		return err
+	})
+	return data, err
+}
+
+func (f *sftpFiles) put(name string, data []byte) error {
+	return f.with(func(c *sftpConn) error {
+		target := f.remotePath(name)
+		err := c.writeFile(target, data)
+		var statusErr *sftpStatusError
+		// 上级目录不存在时先创建目录
+		if errors.As(err, &statusErr) && statusErr.code == sftpNoSuchFile {
+			if err := c.mkdirAll(path.Dir(target)); err != nil {
+				return err
+			}
+			err = c.writeFile(target, data)
+		}
+		return err
+	})
+}
+
+// 在 SFTP 连接上执行操作，连接出错时关闭，下次使用时重新连接
+func (f *sftpFiles) with(op func(c *sftpConn) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conn == nil {
+		if err := f.connect(); err != nil {
+			return err
+		}
+	}
+	err := op(f.conn)
+	var statusErr *sftpStatusError
+	if err != nil && !errors.As(err, &statusErr) {
+		f.client.Close()
+		f.client, f.conn = nil, nil
+	}
+	return err
+}
+
+func (f *sftpFiles) connect() error {
+	hostKey, err := sftpHostKeyCallback(f.config)
+	if err != nil {
+		return err
+	}
+
+	var auth []ssh.AuthMethod
+	if f.config.SFTPKeyFile != "" {
+		key, err := os.ReadFile(f.config.SFTPKeyFile)
+		if err != nil {
+			return fmt.Errorf("error reading SFTP_KEY_FILE: %v", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return fmt.Errorf("error parsing SFTP_KEY_FILE: %v", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if f.config.SFTPPassword != "" {
+		auth = append(auth, ssh.Password(f.config.SFTPPassword))
+	}
+
+	addr := f.config.SFTPAddr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            f.config.SFTPUser,
+		Auth:            auth,
+		HostKeyCallback: hostKey,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+
+	conn, err := newSFTPConn(client)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	f.client, f.conn = client, conn
+	return nil
+}
+
+// 校验服务器的主机密钥：SFTP_HOST_KEY 指定 SHA256 指纹（ssh-keygen -lf 的输出，例如 SHA256:abc...），
+// 未设置时使用 SFTP_KNOWN_HOSTS 文件，默认 ~/.ssh/known_hosts
+func sftpHostKeyCallback(config Config) (ssh.HostKeyCallback, error) {
+	if config.SFTPHostKey != "" {
+		want := config.SFTPHostKey
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if got := ssh.FingerprintSHA256(key); got != want {
+				return fmt.Errorf("host key mismatch for %s: got %s, expected %s", hostname, got, want)
+			}
+			return nil
+		}, nil
+	}
+
+	file := config.SFTPKnownHosts
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("SFTP_HOST_KEY or SFTP_KNOWN_HOSTS is required: %v", err)
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading known hosts, set SFTP_HOST_KEY or SFTP_KNOWN_HOSTS: %v", err)
+	}
+	return callback, nil
+}
+
+// 一个 SFTP 会话，请求逐个发送并等待响应
+type sftpConn struct {
+	w      io.WriteCloser
+	r      io.Reader
+	nextID uint32
+}
+
+func newSFTPConn(client *ssh.Client) (*sftpConn, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, fmt.Errorf("error starting sftp subsystem: %v", err)
+	}
+
+	c := &sftpConn{w: w, r: r}
+	var init sftpPacket
+	init.uint32(3)
+	if err := c.send(sftpInit, init); err != nil {
+		return nil, err
+	}
+	typ, _, err := c.receive()
+	if err != nil {
+		return nil, err
+	}
+	if typ != sftpVersion {
+		return nil, fmt.Errorf("unexpected sftp packet %d during handshake", typ)
+	}
+	return c, nil
+}
+
+// 报文内容的编码
+type sftpPacket []byte
+
+func (p *sftpPacket) byte(v byte) { *p = append(*p, v) }
+
+func (p *sftpPacket) uint32(v uint32) { *p = binary.BigEndian.AppendUint32(*p, v) }
+
+func (p *sftpPacket) uint64(v uint64) { *p = binary.BigEndian.AppendUint64(*p, v) }
+
+func (p *sftpPacket) string(v []byte) {
+	p.uint32(uint32(len(v)))
+	*p = append(*p, v...)
+}
+
+// 报文内容的解码，长度不足时返回零值
+type sftpReader []byte
+
+func (r *sftpReader) uint32() uint32 {
+	if len(*r) < 4 {
+		*r = nil
+		return 0
+	}
+	v := binary.BigEndian.Uint32(*r)
+	*r = (*r)[4:]
+	return v
+}
+
+func (r *sftpReader) string() []byte {
+	n := r.uint32()
+	if uint32(len(*r)) < n {
+		*r = nil
+		return nil
+	}
+	v := (*r)[:n]
+	*r = (*r)[n:]
+	return v
+}
+
+func (c *sftpConn) send(typ byte, body sftpPacket) error {
+	var p sftpPacket
+	p.uint32(uint32(len(body) + 1))
+	p.byte(typ)
+	p = append(p, body...)
+	_, err := c.w.Write(p)
+	return err
+}
+
+func (c *sftpConn) receive() (byte, sftpReader, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > 1<<20 {
+		return 0, nil, fmt.Errorf("invalid sftp packet length %d", length)
+	}
+	body := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[4], body, nil
+}
+
+// 发送带请求 ID 的请求并返回响应，响应的请求 ID 已去掉
+func (c *sftpConn) request(typ byte, fill func(p *sftpPacket)) (byte, sftpReader, error) {
+	c.nextID++
+	id := c.nextID
+	var p sftpPacket
+	p.uint32(id)
+	fill(&p)
+	if err := c.send(typ, p); err != nil {
+		return 0, nil, err
+	}
+
+	respType, body, err := c.receive()
+	if err != nil {
+		return 0, nil, err
+	}
+	if got := body.uint32(); got != id {
+		return 0, nil, fmt.Errorf("unexpected sftp response id %d, expected %d", got, id)
+	}
+	return respType, body, nil
+}
+
+// 将 STATUS 响应转为错误，OK 返回 nil
+func sftpStatusOf(typ byte, body sftpReader) error {
+	if typ != sftpStatus {
+		return fmt.Errorf("unexpected sftp packet %d", typ)
+	}
+	code := body.uint32()
+	if code == sftpOK {
+		return nil
+	}
+	return &sftpStatusError{code: code, message: string(body.string())}
+}
+
+func (c *sftpConn) open(name string, flags uint32) ([]byte, error) {
+	typ, body, err := c.request(sftpOpen, func(p *sftpPacket) {
+		p.string([]byte(name))
+		p.uint32(flags)
+		// 不设置文件属性
+		p.uint32(0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if typ != sftpHandle {
+		return nil, sftpStatusOf(typ, body)
+	}
+	return body.string(), nil
+}
+
+func (c *sftpConn) close(handle []byte) error {
+	typ, body, err := c.request(sftpClose, func(p *sftpPacket) { p.string(handle) })
+	if err != nil {
+		return err
+	}
+	return sftpStatusOf(typ, body)
+}
+
+// 读取整个文件，文件不存在时返回 nil
+func (c *sftpConn) readFile(name string) ([]byte, error) {
+	handle, err := c.open(name, sftpFlagRead)
+	var statusErr *sftpStatusError
+	if errors.As(err, &statusErr) && statusErr.code == sftpNoSuchFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{}
+	for {
+		typ, body, err := c.request(sftpRead, func(p *sftpPacket) {
+			p.string(handle)
+			p.uint64(uint64(len(data)))
+			p.uint32(sftpChunkSize)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if typ == sftpData {
+			data = append(data, body.string()...)
+			continue
+		}
+		err = sftpStatusOf(typ, body)
+		if errors.As(err, &statusErr) && statusErr.code == sftpEOF {
+			break
+		}
+		if err == nil {
+			err = fmt.Errorf("unexpected sftp status while reading %s", name)
+		}
+		c.close(handle)
+		return nil, err
+	}
+	return data, c.close(handle)
+}
+
+// 写入整个文件，已存在时覆盖
+func (c *sftpConn) writeFile(name string, data []byte) error {
+	handle, err := c.open(name, sftpFlagWrite|sftpFlagCreat|sftpFlagTrunc)
+	if err != nil {
+		return err
+	}
+	for offset := 0; offset < len(data); offset += sftpChunkSize {
+		end := min(offset+sftpChunkSize, len(data))
+		typ, body, err := c.request(sftpWrite, func(p *sftpPacket) {
+			p.string(handle)
+			p.uint64(uint64(offset))
+			p.string(data[offset:end])
+		})
+		if err == nil {
+			err = sftpStatusOf(typ, body)
+		}
+		if err != nil {
+			c.close(handle)
+			return err
+		}
+	}
+	return c.close(handle)
+}
+
+// 逐级创建目录，忽略已存在的目录
+func (c *sftpConn) mkdirAll(dir string) error {
+	var current string
+	if strings.HasPrefix(dir, "/") {
+		current = "/"
+	}
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = path.Join(current, part)
+		typ, body, err := c.request(sftpMkdir, func(p *sftpPacket) {
+			p.string([]byte(current))
+			p.uint32(0)
+		})
+		if err != nil {
+			return err
+		}
+		// 目录已存在时服务器通常返回 FAILURE，交给之后的写入报告真正的错误
+		sftpStatusOf(typ, body)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerStorage("webdav", newWebDAVStorage)
+}
+
+// WebDAV 请求客户端，不经过抓取网页使用的代理
+var webdavHTTPClient = &http.Client{Timeout: time.Second * 60}
+
+// 通过 WebDAV 发布数据，例如 Nextcloud、坚果云、Apache mod_dav
+type webdavFiles struct {
+	// 数据目录的地址，以 / 结尾
+	base     string
+	user     string
+	password string
+}
+
+func newWebDAVStorage(config Config) (Storage, error) {
+	if !isHTTPURL(config.WebDAVURL) {
+		return nil, fmt.Errorf("WEBDAV_URL must be an http:// or https:// URL")
+	}
+	files := &webdavFiles{
+		base:     strings.TrimSuffix(config.WebDAVURL, "/") + "/",
+		user:     config.WebDAVUser,
+		password: config.WebDAVPassword,
+	}
+	return &remoteStorage{config: config, kind: "WebDAV", files: files, feedsFile: config.WebDAVFeedsFile}, nil
+}
+
+func (f *webdavFiles) do(method string, name string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, f.base+name, reader)
+	if err != nil {
+		return nil, err
+	}
+	if f.user != "" {
+		req.SetBasicAuth(f.user, f.password)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentTypeOf(name))
+	}
+	return webdavHTTPClient.Do(req)
+}
+
+func (f *webdavFiles) get(name string) ([]byte, error) {
+	resp, err := f.do(http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (f *webdavFiles) put(name string, data []byte) error {
+	status, err := f.upload(name, data)
+	if err != nil {
+		return err
+	}
+	// 上级目录不存在时返回 409，创建目录后重试
+	if status == http.StatusConflict {
+		if err := f.mkdirAll(path.Dir(name)); err != nil {
+			return err
+		}
+		if status, err = f.upload(name, data); err != nil {
+			return err
+		}
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("unexpected status %d", status)
+	}
+	return nil
+}
+
+func (f *webdavFiles) upload(name string, data []byte) (int, error) {
+	resp, err := f.do(http.MethodPut, name, data)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// 逐级创建目录，已存在的目录返回 405
+func (f *webdavFiles) mkdirAll(dir string) error {
+	if dir == "." || dir == "/" || dir == "" {
+		return nil
+	}
+	if err := f.mkdirAll(path.Dir(dir)); err != nil {
+		return err
+	}
+	resp, err := f.do("MKCOL", dir+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("error creating directory %s: unexpected status %s", dir, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// 常见的友链页面路径，RSS 未指定 blogroll= 时依次尝试
+var blogrollPaths = []string{"/links", "/links/", "/friends", "/friends/", "/link", "/blogroll", "/links.html", "/friends.html"}
+
+// 友链中常见但不是博客的站点
+var nonBlogHosts = map[string]bool{
+	"github.com":          true,
+	"twitter.com":         true,
+	"x.com":               true,
+	"weibo.com":           true,
+	"zhihu.com":           true,
+	"bilibili.com":        true,
+	"space.bilibili.com":  true,
+	"beian.miit.gov.cn":   true,
+	"creativecommons.org": true,
+	"gohugo.io":           true,
+	"hexo.io":             true,
+	"wordpress.org":       true,
+	"typecho.org":         true,
+}
+
+// 推荐的博客
+type suggestion struct {
+	// 博客主页
+	URL string `json:"url"`
+	// 链接到该博客的朋友数量
+	Score int `json:"score"`
+	// 链接到该博客的朋友
+	LinkedBy []string `json:"linkedBy"`
+}
+
+// 获取 URL 的主机名，去掉 www. 前缀便于比较
+func normalizedHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// 抓取朋友的友链页面，返回页面中的外部链接
+func fetchBlogrollLinks(spec feedSpec) ([]string, error) {
+	var pages []string
+	if blogroll := spec.Options["blogroll"]; blogroll != "" {
+		pages = []string{blogroll}
+	} else {
+		u, err := url.Parse(spec.URL)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range blogrollPaths {
+			pages = append(pages, u.Scheme+"://"+u.Host+p)
+		}
+	}
+
+	for _, page := range pages {
+		if checkRobots(context.Background(), page) != nil {
+			continue
+		}
+		resp, err := httpClient.Get(page)
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+			resp.Body.Close()
+			continue
+		}
+
+		base, _ := url.Parse(page)
+		links := extractLinks(html.NewTokenizer(resp.Body), base)
+		resp.Body.Close()
+		return links, nil
+	}
+
+	return nil, fmt.Errorf("no blogroll page found")
+}
+
+// 提取页面中所有 <a href> 的绝对地址
+func extractLinks(z *html.Tokenizer, base *url.URL) []string {
+	var links []string
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return links
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		if string(name) != "a" || !hasAttr {
+			continue
+		}
+		for {
+			key, val, more := z.TagAttr()
+			if string(key) == "href" {
+				if ref, err := url.Parse(strings.TrimSpace(string(val))); err == nil {
+					links = append(links, base.ResolveReference(ref).String())
+				}
+			}
+			if !more {
+				break
+			}
+		}
+	}
+}
+
+// 从朋友的友链中发现尚未订阅的博客，按被链接次数排序后写入 suggestions.json
+func runSuggest(config Config, store Storage) error {
+	lines, err := store.ReadFeeds()
+	if err != nil {
+		return err
+	}
+	specs := parseFeedList(lines)
+
+	// 已订阅的博客
+	subscribed := map[string]bool{}
+	for _, spec := range specs {
+		subscribed[normalizedHost(spec.URL)] = true
+	}
+
+	// 屏蔽列表中的博客不再推荐
+	blocked, err := loadBlocklist(store)
+	if err != nil {
+		return err
+	}
+
+	// 候选博客 -> 链接到它的朋友
+	candidates := map[string]map[string]bool{}
+	homepages := map[string]string{}
+
+	for _, spec := range specs {
+		friend := normalizedHost(spec.URL)
+		links, err := fetchBlogrollLinks(spec)
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", spec.URL, err)
+			continue
+		}
+
+		for _, link := range links {
+			u, err := url.Parse(link)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+			host := normalizedHost(link)
+			if host == "" || host == friend || subscribed[host] || nonBlogHosts[host] {
+				continue
+			}
+			if _, ok := blocked.match(link); ok {
+				continue
+			}
+
+			if candidates[host] == nil {
+				candidates[host] = map[string]bool{}
+				homepages[host] = u.Scheme + "://" + u.Host
+			}
+			candidates[host][friend] = true
+		}
+	}
+
+	suggestions := make([]suggestion, 0, len(candidates))
+	for host, friends := range candidates {
+		if len(friends) < config.SuggestMinScore {
+			continue
+		}
+
+		s := suggestion{URL: homepages[host], Score: len(friends)}
+		for friend := range friends {
+			s.LinkedBy = append(s.LinkedBy, friend)
+		}
+		sort.Strings(s.LinkedBy)
+		suggestions = append(suggestions, s)
+	}
+
+	// 被链接次数多的在前
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].URL < suggestions[j].URL
+	})
+
+	jsonData, err := json.MarshalIndent(suggestions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Found %d suggestions\n", len(suggestions))
+	return store.WriteFile("suggestions.json", jsonData)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 需要去除的不可见字符：零宽字符、BOM、软连字符和双向文本控制符。
+// 部分博客程序会在标题中输出这些字符，导致看起来相同的文章重复出现、前端排序错乱
+func invisibleRune(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u2060', '\ufeff', '\u00ad',
+		'\u200e', '\u200f', '\u061c':
+		return true
+	}
+	return r >= '\u202a' && r <= '\u202e' || r >= '\u2066' && r <= '\u2069'
+}
+
+// 规范化标题、博客名称等文本：去除不可见字符，转换为 NFC，合并连续空白。
+// 零宽连接符（U+200D）用于组合 emoji，只在 emoji 之后保留
+func cleanText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	var prev rune
+	for _, r := range s {
+		if invisibleRune(r) {
+			continue
+		}
+		if r == '\u200d' && !unicode.Is(unicode.So, prev) && prev != '\ufe0f' {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+
+	return strings.Join(strings.Fields(norm.NFC.String(b.String())), " ")
+}
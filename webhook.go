@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 推送给 Webhook 的新文章事件
+type webhookPayload struct {
+	// 事件 ID，同一篇文章推送到同一地址时不变，接收方可以据此去重
+	ID string `json:"id"`
+	// 事件类型，目前只有 article.new
+	Event   string  `json:"event"`
+	Article Article `json:"article"`
+}
+
+// 解析 WEBHOOK_URLS，多个地址用逗号分隔
+func webhookURLs(config Config) []string {
+	var urls []string
+	for _, u := range strings.Split(config.WebhookURLs, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// 为每篇新文章和每个 Webhook 地址生成一个发件箱事件
+func webhookEvents(config Config, fresh []Article) ([]outboxEvent, error) {
+	var events []outboxEvent
+	for _, u := range webhookURLs(config) {
+		for _, article := range fresh {
+			event, err := webhookEvent(config, u, articleKey(article), article)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// 生成一篇文章推送到一个地址的事件，key 为文章的唯一标识（articleKey）。
+// 配置了 Webhook 模板时按模板生成请求体
+func webhookEvent(config Config, url string, key string, article Article) (outboxEvent, error) {
+	id := outboxEventID("webhook", url, key)
+	event := webhookPayload{ID: id, Event: "article.new", Article: article}
+
+	payload, err := webhookBody(config, url, event)
+	if err != nil {
+		return outboxEvent{}, err
+	}
+	if payload == nil {
+		if payload, err = json.Marshal(event); err != nil {
+			return outboxEvent{}, err
+		}
+	}
+	return outboxEvent{ID: id, Channel: "webhook", Target: url, Payload: string(payload)}, nil
+}
+
+// 发送一次 Webhook 请求，非 2xx 响应视为失败
+func postWebhook(url string, id string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Grab-latest-RSS")
+	req.Header.Set("X-Event-ID", id)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}